@@ -4,20 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/uber/jaeger-lib/metrics"
+	jgrProm "github.com/uber/jaeger-lib/metrics/prometheus"
 	"github.com/ydb-platform/ydb-go-sdk/v3"
 	"github.com/ydb-platform/ydb-go-sdk/v3/scheme"
 	"github.com/ydb-platform/ydb-go-sdk/v3/sugar"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
 	"go.uber.org/zap"
 
+	"github.com/ydb-platform/jaeger-ydb-store/cmd/schema/dependencies"
+	"github.com/ydb-platform/jaeger-ydb-store/cmd/schema/reindex"
 	"github.com/ydb-platform/jaeger-ydb-store/cmd/schema/watcher"
 	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
 	localViper "github.com/ydb-platform/jaeger-ydb-store/internal/viper"
@@ -29,13 +35,18 @@ func init() {
 	viper.SetDefault("watcher_interval", time.Minute*5)
 	viper.SetDefault("watcher_age", time.Hour*24)
 	viper.SetDefault("watcher_lookahead", time.Hour*12)
+	viper.SetDefault("watcher_archive_batch_size", 1000)
+	viper.SetDefault("watcher_archive_interval", time.Hour*6)
+	viper.SetDefault("watcher_auto_archive_max_attempts", 3)
 	viper.SetDefault("parts_traces", 32)
 	viper.SetDefault("parts_idx_tag", 32)
 	viper.SetDefault("parts_idx_tag_v2", 32)
 	viper.SetDefault("parts_idx_duration", 32)
 	viper.SetDefault("parts_idx_service_name", 32)
 	viper.SetDefault("parts_idx_service_op", 32)
+	viper.SetDefault("dependencies_lookback", time.Hour*24)
 	viper.SetDefault(db.KeyYDBPartitionSize, "1024mb")
+	viper.SetDefault(db.KeyYDBDependenciesTTL, time.Hour*24*30)
 	viper.AutomaticEnv()
 }
 
@@ -74,10 +85,29 @@ func main() {
 					Path:   viper.GetString(db.KeyYdbPath),
 					Folder: viper.GetString(db.KeyYdbFolder),
 				},
+				ArchiveRetention: viper.GetDuration("watcher_archive_retention"),
+				ArchiveBatchSize: viper.GetInt("watcher_archive_batch_size"),
+				ArchiveDryRun:    viper.GetBool("watcher_archive_dry_run"),
+				ArchiveInterval:  viper.GetDuration("watcher_archive_interval"),
+
+				AutoArchiveMaxAttempts: viper.GetInt("watcher_auto_archive_max_attempts"),
 			}
 			if opts.Expiration == 0 {
 				return fmt.Errorf("cannot use watcher age '%s'", opts.Expiration)
 			}
+			if opts.ArchiveRetention < 0 {
+				return fmt.Errorf("cannot use archive retention '%s'", opts.ArchiveRetention)
+			}
+			if err := localViper.UnmarshalListKey(viper.GetViper(), "watcher_auto_archive_rules", &opts.AutoArchiveRules); err != nil {
+				return err
+			}
+			if len(opts.AutoArchiveRules) > 0 && opts.ArchiveRetention > 0 && opts.ArchiveRetention <= opts.Expiration {
+				// archived traces keep their start time, retention would delete them right after copy
+				return fmt.Errorf("archive retention '%s' must be greater than watcher age '%s' with auto archive rules", opts.ArchiveRetention, opts.Expiration)
+			}
+			if addr := viper.GetString("watcher_metrics_address"); addr != "" {
+				go serveMetrics(addr, logger)
+			}
 
 			shutdown := make(chan os.Signal, 1)
 			signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
@@ -87,7 +117,11 @@ func main() {
 			}
 
 			logger.Info("starting watcher")
-			w := watcher.NewWatcher(opts, conn.Table(), logger)
+			mf := jgrProm.New().Namespace(metrics.NSOptions{Name: "jaeger_ydb_watcher"})
+			w, err := watcher.NewWatcher(opts, conn.Table(), mf, logger)
+			if err != nil {
+				return err
+			}
 			w.Run(viper.GetDuration("watcher_interval"))
 			<-shutdown
 			logger.Info("stopping watcher")
@@ -129,7 +163,93 @@ func main() {
 			return nil
 		},
 	}
-	command.AddCommand(watcherCmd, dropCmd)
+	dependenciesCmd := &cobra.Command{
+		Use:   "dependencies",
+		Short: "rebuild service dependency links from stored spans",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			shutdown := make(chan os.Signal, 1)
+			signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				<-shutdown
+				cancel()
+			}()
+
+			opts := dependencies.Options{
+				DBPath: schema.DbPath{
+					Path:   viper.GetString(db.KeyYdbPath),
+					Folder: viper.GetString(db.KeyYdbFolder),
+				},
+				End: time.Now(),
+			}
+			var err error
+			if end := viper.GetString("dependencies_end"); end != "" {
+				if opts.End, err = time.Parse(time.RFC3339, end); err != nil {
+					return fmt.Errorf("cannot parse end time: %w", err)
+				}
+			}
+			opts.Start = opts.End.Add(-viper.GetDuration("dependencies_lookback"))
+			if start := viper.GetString("dependencies_start"); start != "" {
+				if opts.Start, err = time.Parse(time.RFC3339, start); err != nil {
+					return fmt.Errorf("cannot parse start time: %w", err)
+				}
+			}
+			if !opts.Start.Before(opts.End) {
+				return fmt.Errorf("start time '%s' must be before end time '%s'", opts.Start, opts.End)
+			}
+
+			conn, err := ydbConn(ctx, viper.GetViper(), nil)
+			if err != nil {
+				return fmt.Errorf("failed to create table client: %w", err)
+			}
+			defer func() {
+				_ = conn.Close(context.Background())
+			}()
+
+			logger.Info("building dependencies", zap.Time("start", opts.Start), zap.Time("end", opts.End))
+			return dependencies.NewJob(opts, conn.Table(), logger).Run(ctx)
+		},
+	}
+	dependenciesCmd.Flags().String("start", "", "range start in RFC3339 format, defaults to end - lookback (env: DEPENDENCIES_START)")
+	dependenciesCmd.Flags().String("end", "", "range end in RFC3339 format, defaults to now (env: DEPENDENCIES_END)")
+	dependenciesCmd.Flags().Duration("lookback", time.Hour*24, "range length used when start is not set (env: DEPENDENCIES_LOOKBACK)")
+	_ = viper.BindPFlag("dependencies_start", dependenciesCmd.Flags().Lookup("start"))
+	_ = viper.BindPFlag("dependencies_end", dependenciesCmd.Flags().Lookup("end"))
+	_ = viper.BindPFlag("dependencies_lookback", dependenciesCmd.Flags().Lookup("lookback"))
+
+	archiveReindexCmd := &cobra.Command{
+		Use:   "archive-reindex",
+		Short: "index archived spans and register their service and operation names",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			shutdown := make(chan os.Signal, 1)
+			signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				<-shutdown
+				cancel()
+			}()
+
+			conn, err := ydbConn(ctx, viper.GetViper(), nil)
+			if err != nil {
+				return fmt.Errorf("failed to create table client: %w", err)
+			}
+			defer func() {
+				_ = conn.Close(context.Background())
+			}()
+
+			opts := reindex.Options{
+				DBPath: schema.DbPath{
+					Path:   viper.GetString(db.KeyYdbPath),
+					Folder: viper.GetString(db.KeyYdbFolder),
+				},
+			}
+			return reindex.NewJob(opts, conn.Table(), logger).Run(ctx)
+		},
+	}
+
+	command.AddCommand(watcherCmd, dropCmd, dependenciesCmd, archiveReindexCmd)
 
 	err = command.Execute()
 	if err != nil {
@@ -137,6 +257,15 @@ func main() {
 	}
 }
 
+func serveMetrics(addr string, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	logger.Info("serving metrics", zap.String("addr", addr))
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logger.Fatal("failed to start http listener", zap.Error(err))
+	}
+}
+
 func ydbConn(ctx context.Context, v *viper.Viper, l *zap.Logger) (*ydb.Driver, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
@@ -0,0 +1,179 @@
+package dependencies
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/dependencystore"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+const (
+	tblTraces = "traces"
+)
+
+type Options struct {
+	DBPath       schema.DbPath
+	Start        time.Time
+	End          time.Time
+	WriteTimeout time.Duration
+}
+
+// Job rebuilds dependency links from partition tables for a time range.
+// Rows are written with a fixed uniq value, so running the job again for the same range
+// overwrites previous results instead of adding to them. Rows are marked with job source,
+// so links aggregated by span writer for the same buckets aren't counted with them.
+type Job struct {
+	sessionProvider table.Client
+	opts            Options
+	logger          *zap.Logger
+	writer          *dependencystore.Writer
+}
+
+func NewJob(opts Options, sp table.Client, logger *zap.Logger) *Job {
+	// align range to dependency buckets, otherwise partial buckets would overwrite complete ones
+	opts.Start = opts.Start.UTC().Truncate(dbmodel.DependencyBucketStep)
+	if end := opts.End.UTC().Truncate(dbmodel.DependencyBucketStep); !end.Equal(opts.End) {
+		opts.End = end.Add(dbmodel.DependencyBucketStep)
+	}
+	return &Job{
+		sessionProvider: sp,
+		opts:            opts,
+		logger:          logger,
+		writer: dependencystore.NewWriter(sp, metrics.NullFactory, dependencystore.WriterOptions{
+			DbPath:       opts.DBPath,
+			Source:       dbmodel.DependencySourceJob,
+			WriteTimeout: opts.WriteTimeout,
+		}),
+	}
+}
+
+// Run scans partitions and saves links for every bucket within range
+func (j *Job) Run(ctx context.Context) error {
+	buckets := make(map[int64]map[dependencystore.Link]uint64)
+	for _, part := range schema.MakePartitionList(j.opts.Start, j.opts.End) {
+		j.logger.Info("scanning partition", zap.String("suffix", part.Suffix()))
+		n, partBuckets, err := j.scanPartition(ctx, part)
+		if err != nil {
+			return fmt.Errorf("partition %s scan failed: %w", part.Suffix(), err)
+		}
+		for bucket, links := range partBuckets {
+			if _, ok := buckets[bucket]; !ok {
+				buckets[bucket] = links
+				continue
+			}
+			for link, cnt := range links {
+				buckets[bucket][link] += cnt
+			}
+		}
+		j.logger.Info("partition scanned", zap.String("suffix", part.Suffix()), zap.Int("traces", n))
+	}
+	for bucket, links := range buckets {
+		ts := time.Unix(0, bucket)
+		if err := j.writer.WriteLinks(ctx, ts, 0, links); err != nil {
+			return fmt.Errorf("dependencies write failed: %w", err)
+		}
+		j.logger.Info("dependencies saved", zap.Time("bucket", ts), zap.Int("links", len(links)))
+	}
+	return nil
+}
+
+func (j *Job) scanPartition(ctx context.Context, part schema.PartitionKey) (int, map[int64]map[dependencystore.Link]uint64, error) {
+	fullName := part.BuildFullTableName(j.opts.DBPath.String(), tblTraces)
+	var numTraces int
+	var buckets map[int64]map[dependencystore.Link]uint64
+	err := j.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		// start over on retry
+		numTraces = 0
+		buckets = make(map[int64]map[dependencystore.Link]uint64)
+		res, err := session.StreamReadTable(ctx, fullName,
+			options.ReadOrdered(),
+			options.ReadColumns("trace_id_low", "trace_id_high", "span_id", "operation_name", "flags", "start_time", "duration", "extra"),
+		)
+		if err != nil {
+			if db.IsPathNotExistError(err) {
+				return nil
+			}
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+
+		// rows are ordered by primary key, so spans of the same trace come together
+		var spans []*model.Span
+		processTrace := func() {
+			if len(spans) == 0 {
+				return
+			}
+			numTraces++
+			j.addTrace(spans, buckets)
+			spans = spans[:0]
+		}
+		for res.NextResultSet(ctx, "trace_id_low", "trace_id_high", "span_id", "operation_name", "flags", "start_time", "duration", "extra") {
+			for res.NextRow() {
+				dbSpan := dbmodel.Span{}
+				err = res.ScanWithDefaults(
+					&dbSpan.TraceIDLow,
+					&dbSpan.TraceIDHigh,
+					&dbSpan.SpanID,
+					&dbSpan.OperationName,
+					&dbSpan.Flags,
+					&dbSpan.StartTime,
+					&dbSpan.Duration,
+					&dbSpan.Extra,
+				)
+				if err != nil {
+					return fmt.Errorf("span.Scan failed: %w", err)
+				}
+				span, err := dbmodel.ToDomain(&dbSpan)
+				if err != nil {
+					j.logger.Warn("skip span which can't be decoded",
+						zap.Uint64("trace_id_low", dbSpan.TraceIDLow),
+						zap.Uint64("span_id", dbSpan.SpanID),
+						zap.Error(err),
+					)
+					continue
+				}
+				if len(spans) > 0 && spans[0].TraceID != span.TraceID {
+					processTrace()
+				}
+				spans = append(spans, span)
+			}
+		}
+		if err = res.Err(); err != nil {
+			return err
+		}
+		processTrace()
+		return nil
+	})
+	return numTraces, buckets, err
+}
+
+func (j *Job) addTrace(spans []*model.Span, buckets map[int64]map[dependencystore.Link]uint64) {
+	start := spans[0].StartTime
+	for _, span := range spans[1:] {
+		if span.StartTime.Before(start) {
+			start = span.StartTime
+		}
+	}
+	if start.Before(j.opts.Start) || !start.Before(j.opts.End) {
+		return
+	}
+	bucket := dbmodel.DependencyBucket(start)
+	links, ok := buckets[bucket]
+	if !ok {
+		links = make(map[dependencystore.Link]uint64)
+		buckets[bucket] = links
+	}
+	dependencystore.AddTraceLinks(spans, links)
+}
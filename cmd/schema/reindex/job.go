@@ -0,0 +1,134 @@
+package reindex
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer"
+)
+
+const (
+	tblArchive = "archive"
+
+	writeTimeout = time.Second * 10
+)
+
+type Options struct {
+	DBPath schema.DbPath
+}
+
+// Job writes every archived span through archive span writer again, so archive index tables and
+// archive_service_names / archive_operation_names_v2 get entries for spans archived before they appeared.
+// Spans are upserted by primary key, running the job more than once doesn't duplicate anything.
+type Job struct {
+	sessionProvider table.Client
+	opts            Options
+	logger          *zap.Logger
+}
+
+func NewJob(opts Options, sp table.Client, logger *zap.Logger) *Job {
+	return &Job{
+		sessionProvider: sp,
+		opts:            opts,
+		logger:          logger,
+	}
+}
+
+// Run scans archive table and returns error if any span, index entry or name wasn't written
+func (j *Job) Run(ctx context.Context) error {
+	w := writer.NewSpanWriter(j.sessionProvider, metrics.NullFactory, j.logger, hclog.NewNullLogger(), writer.SpanWriterOptions{
+		ArchiveWriter:     true,
+		BufferSize:        1000,
+		BatchSize:         100,
+		BatchWorkers:      1,
+		IndexerBufferSize: 1000,
+		IndexerMaxTraces:  100,
+		IndexerTTL:        time.Second * 5,
+		DbPath:            j.opts.DBPath,
+		WriteTimeout:      writeTimeout,
+		OpCacheSize:       256,
+		Overflow:          batch.OverflowBlock,
+		OverflowTimeout:   writeTimeout,
+	})
+	n, err := j.scan(ctx, w)
+	// batch write errors are only logged by writer, so spans lost by them are checked as well
+	if lost := w.Close(context.Background()).Lost(); err == nil && lost > 0 {
+		err = fmt.Errorf("%d spans or index entries aren't written", lost)
+	}
+	if err != nil {
+		return err
+	}
+	j.logger.Info("archive reindexed", zap.Int("spans", n))
+	return nil
+}
+
+func (j *Job) scan(ctx context.Context, w *writer.SpanWriter) (int, error) {
+	fullName := j.opts.DBPath.FullTable(tblArchive)
+	var numSpans int
+	err := j.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		// rows written before retry are upserted again, it's harmless
+		numSpans = 0
+		res, err := session.StreamReadTable(ctx, fullName,
+			options.ReadOrdered(),
+			options.ReadColumns("trace_id_low", "trace_id_high", "span_id", "operation_name", "flags", "start_time", "duration", "extra"),
+		)
+		if err != nil {
+			if db.IsPathNotExistError(err) {
+				return nil
+			}
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+
+		for res.NextResultSet(ctx, "trace_id_low", "trace_id_high", "span_id", "operation_name", "flags", "start_time", "duration", "extra") {
+			for res.NextRow() {
+				dbSpan := dbmodel.Span{}
+				err = res.ScanWithDefaults(
+					&dbSpan.TraceIDLow,
+					&dbSpan.TraceIDHigh,
+					&dbSpan.SpanID,
+					&dbSpan.OperationName,
+					&dbSpan.Flags,
+					&dbSpan.StartTime,
+					&dbSpan.Duration,
+					&dbSpan.Extra,
+				)
+				if err != nil {
+					return fmt.Errorf("span.Scan failed: %w", err)
+				}
+				span, otlp, err := dbmodel.ToDomainOtlp(&dbSpan)
+				if err == nil && otlp != nil {
+					// written again with OTLP fields
+					err = dbmodel.AttachOtlp(span, otlp)
+				}
+				if err != nil {
+					j.logger.Warn("skip span which can't be decoded",
+						zap.Uint64("trace_id_low", dbSpan.TraceIDLow),
+						zap.Uint64("span_id", dbSpan.SpanID),
+						zap.Error(err),
+					)
+					continue
+				}
+				if err = w.WriteSpan(ctx, span); err != nil {
+					return fmt.Errorf("reindex span %s: %w", span.SpanID, err)
+				}
+				numSpans++
+			}
+		}
+		return res.Err()
+	})
+	return numSpans, err
+}
@@ -0,0 +1,395 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/indexed"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+const (
+	defaultArchiveBatchSize = 1000
+
+	tblArchiveServiceNames   = "archive_service_names"
+	tblArchiveOperationNames = "archive_operation_names_v2"
+)
+
+// archiveColumn is primary key column of archive table, type is one of Uint64, Int64 or Uint32
+type archiveColumn struct {
+	name string
+	typ  string
+}
+
+func (c archiveColumn) scanTarget() interface{} {
+	switch c.typ {
+	case "Int64":
+		return new(int64)
+	case "Uint32":
+		return new(uint32)
+	default:
+		return new(uint64)
+	}
+}
+
+func (c archiveColumn) value(target interface{}) types.Value {
+	switch v := target.(type) {
+	case *int64:
+		return types.Int64Value(*v)
+	case *uint32:
+		return types.Uint32Value(*v)
+	default:
+		return types.Uint64Value(*target.(*uint64))
+	}
+}
+
+// archiveTable describes rows of archive storage table which expire by span start time
+type archiveTable struct {
+	name string
+	keys []archiveColumn
+	// timeColumn keeps span start time in unix nanoseconds, rev_start_time keeps it negated
+	timeColumn string
+}
+
+var (
+	colIdxHash      = archiveColumn{"idx_hash", "Uint64"}
+	colRevStartTime = archiveColumn{"rev_start_time", "Int64"}
+	colUniq         = archiveColumn{"uniq", "Uint32"}
+)
+
+// archiveTables are ordered so indexes are cleaned first, expired trace is never found without its spans
+var archiveTables = []archiveTable{
+	{"archive_idx_service_name", []archiveColumn{colIdxHash, colRevStartTime, colUniq}, "rev_start_time"},
+	{"archive_idx_service_op", []archiveColumn{colIdxHash, colRevStartTime, colUniq}, "rev_start_time"},
+	{"archive_idx_duration", []archiveColumn{colIdxHash, {"duration", "Int64"}, colRevStartTime, colUniq}, "rev_start_time"},
+	{"archive_idx_tag_v2", []archiveColumn{colIdxHash, colRevStartTime, {"op_hash", "Uint64"}, colUniq}, "rev_start_time"},
+	{"archive", []archiveColumn{{"trace_id_low", "Uint64"}, {"trace_id_high", "Uint64"}, {"span_id", "Uint64"}}, "start_time"},
+}
+
+// columns returns key columns followed by time column if it isn't a key
+func (t archiveTable) columns() []string {
+	cols := make([]string, 0, len(t.keys)+1)
+	timeIdx := -1
+	for i, c := range t.keys {
+		cols = append(cols, c.name)
+		if c.name == t.timeColumn {
+			timeIdx = i
+		}
+	}
+	if timeIdx < 0 {
+		cols = append(cols, t.timeColumn)
+	}
+	return cols
+}
+
+// expired checks value of time column against unix nanoseconds of retention start
+func (t archiveTable) expired(ts, timeMax int64) bool {
+	if t.timeColumn == "rev_start_time" {
+		return -ts < timeMax
+	}
+	return ts < timeMax
+}
+
+func (t archiveTable) deleteQuery(dbPath schema.DbPath) string {
+	fields := make([]string, 0, len(t.keys))
+	for _, c := range t.keys {
+		fields = append(fields, c.name+": "+c.typ)
+	}
+	return fmt.Sprintf(`DECLARE $keys AS List<Struct<%s>>;
+DELETE FROM `+"`%s`"+` ON SELECT * FROM AS_TABLE($keys);`, strings.Join(fields, ", "), dbPath.FullTable(t.name))
+}
+
+type archiveMetrics struct {
+	deleted metrics.Counter
+	expired metrics.Gauge
+}
+
+// archiveRetention deletes archived spans and their index entries older than retention.
+// Every table is read once per run and expired rows are deleted by primary key in batches,
+// then service and operation names without index entries left are deleted.
+// In dry-run mode expired rows are only counted.
+type archiveRetention struct {
+	sessionProvider table.Client
+	opts            Options
+	logger          *zap.Logger
+	errors          metrics.Counter
+	tables          map[string]archiveMetrics
+}
+
+func newArchiveRetention(sp table.Client, opts Options, mf metrics.Factory, logger *zap.Logger) *archiveRetention {
+	if opts.ArchiveBatchSize <= 0 {
+		opts.ArchiveBatchSize = defaultArchiveBatchSize
+	}
+	r := &archiveRetention{
+		sessionProvider: sp,
+		opts:            opts,
+		logger:          logger,
+		errors:          mf.Counter(metrics.Options{Name: "archive_retention_errors"}),
+		tables:          make(map[string]archiveMetrics, len(archiveTables)+2),
+	}
+	names := []string{tblArchiveServiceNames, tblArchiveOperationNames}
+	for _, t := range archiveTables {
+		names = append(names, t.name)
+	}
+	for _, name := range names {
+		tags := map[string]string{"table": name}
+		r.tables[name] = archiveMetrics{
+			deleted: mf.Counter(metrics.Options{Name: "archive_deleted_rows", Tags: tags}),
+			expired: mf.Gauge(metrics.Options{Name: "archive_expired_rows", Tags: tags}),
+		}
+	}
+	return r
+}
+
+func (r *archiveRetention) once(ctx context.Context) {
+	expireTime := time.Now().Add(-r.opts.ArchiveRetention)
+	r.logger.Info("delete expired archive rows", zap.Time("before", expireTime), zap.Bool("dry_run", r.opts.ArchiveDryRun))
+	for _, t := range archiveTables {
+		n, err := r.deleteExpired(ctx, t, expireTime.UnixNano())
+		if err != nil {
+			r.errors.Inc(1)
+			r.logger.Error("archive retention failed", zap.String("table", t.name), zap.Error(err))
+			// keep order of tables, spans aren't deleted while their indexes are left
+			return
+		}
+		r.report(t.name, n)
+	}
+	if err := r.deleteServiceNames(ctx); err != nil {
+		r.errors.Inc(1)
+		r.logger.Error("archive retention failed", zap.String("table", tblArchiveServiceNames), zap.Error(err))
+	}
+	if err := r.deleteOperationNames(ctx); err != nil {
+		r.errors.Inc(1)
+		r.logger.Error("archive retention failed", zap.String("table", tblArchiveOperationNames), zap.Error(err))
+	}
+}
+
+// report updates metrics with number of rows expired or deleted by run
+func (r *archiveRetention) report(tableName string, n int64) {
+	if r.opts.ArchiveDryRun {
+		r.tables[tableName].expired.Update(n)
+		r.logger.Info("expired archive rows", zap.String("table", tableName), zap.Int64("rows", n))
+		return
+	}
+	if n > 0 {
+		r.logger.Info("deleted archive rows", zap.String("table", tableName), zap.Int64("rows", n))
+	}
+}
+
+// deleteExpired reads table once and deletes expired rows in batches, returns number of expired rows
+func (r *archiveRetention) deleteExpired(ctx context.Context, t archiveTable, timeMax int64) (int64, error) {
+	var n int64
+	cols := t.columns()
+	err := r.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		// deleted rows aren't read again on retry
+		n = 0
+		res, err := session.StreamReadTable(ctx, r.opts.DBPath.FullTable(t.name), options.ReadColumns(cols...))
+		if err != nil {
+			if db.IsPathNotExistError(err) {
+				return nil
+			}
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+		targets := make([]indexed.Required, 0, len(cols))
+		for _, c := range t.keys {
+			targets = append(targets, c.scanTarget())
+		}
+		var ts *int64
+		if len(cols) > len(t.keys) {
+			ts = new(int64)
+			targets = append(targets, ts)
+		} else {
+			for i, c := range t.keys {
+				if c.name == t.timeColumn {
+					ts = targets[i].(*int64)
+				}
+			}
+		}
+
+		batch := make([]types.Value, 0, r.opts.ArchiveBatchSize)
+		for res.NextResultSet(ctx, cols...) {
+			for res.NextRow() {
+				if err = res.ScanWithDefaults(targets...); err != nil {
+					return fmt.Errorf("archive row scan failed: %w", err)
+				}
+				if !t.expired(*ts, timeMax) {
+					continue
+				}
+				n++
+				if r.opts.ArchiveDryRun {
+					continue
+				}
+				fields := make([]types.StructValueOption, 0, len(t.keys))
+				for i, c := range t.keys {
+					fields = append(fields, types.StructFieldValue(c.name, c.value(targets[i])))
+				}
+				batch = append(batch, types.StructValue(fields...))
+				if len(batch) >= r.opts.ArchiveBatchSize {
+					if err = r.deleteRows(ctx, t.name, t.deleteQuery(r.opts.DBPath), batch); err != nil {
+						return err
+					}
+					batch = batch[:0]
+				}
+			}
+		}
+		if err = res.Err(); err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			return r.deleteRows(ctx, t.name, t.deleteQuery(r.opts.DBPath), batch)
+		}
+		return nil
+	})
+	return n, err
+}
+
+// deleteRows deletes rows by keys with query declaring $keys list
+func (r *archiveRetention) deleteRows(ctx context.Context, tableName, query string, keys []types.Value) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+	err := r.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		_, _, err := session.Execute(ctx, txc, query, table.NewQueryParameters(
+			table.ValueParam("$keys", types.ListValue(keys...)),
+		))
+		return err
+	})
+	if err == nil {
+		r.tables[tableName].deleted.Inc(int64(len(keys)))
+	}
+	return err
+}
+
+// deleteServiceNames deletes services without entries in archive service index,
+// their index rows are looked up by primary key in every bucket
+func (r *archiveRetention) deleteServiceNames(ctx context.Context) error {
+	services, err := r.readNames(ctx, tblArchiveServiceNames, "service_name")
+	if err != nil {
+		return err
+	}
+	var stale []types.Value
+	for _, row := range services {
+		hashes := make([]types.Value, 0, dbmodel.NumIndexBuckets)
+		for bucket := uint8(0); bucket < dbmodel.NumIndexBuckets; bucket++ {
+			hashes = append(hashes, types.Uint64Value(dbmodel.HashBucketData(bucket, row[0])))
+		}
+		found, err := r.indexExists(ctx, "archive_idx_service_name", hashes)
+		if err != nil {
+			return err
+		}
+		if !found {
+			stale = append(stale, types.StructValue(types.StructFieldValue("service_name", types.UTF8Value(row[0]))))
+		}
+	}
+	return r.deleteNames(ctx, tblArchiveServiceNames, "service_name: Utf8", stale)
+}
+
+// deleteOperationNames deletes operations without entries in archive service operation index
+func (r *archiveRetention) deleteOperationNames(ctx context.Context) error {
+	operations, err := r.readNames(ctx, tblArchiveOperationNames, "service_name", "span_kind", "operation_name")
+	if err != nil {
+		return err
+	}
+	var stale []types.Value
+	for _, row := range operations {
+		found, err := r.indexExists(ctx, "archive_idx_service_op", []types.Value{types.Uint64Value(dbmodel.HashData(row[0], row[2]))})
+		if err != nil {
+			return err
+		}
+		if !found {
+			stale = append(stale, types.StructValue(
+				types.StructFieldValue("service_name", types.UTF8Value(row[0])),
+				types.StructFieldValue("span_kind", types.UTF8Value(row[1])),
+				types.StructFieldValue("operation_name", types.UTF8Value(row[2])),
+			))
+		}
+	}
+	return r.deleteNames(ctx, tblArchiveOperationNames, "service_name: Utf8, span_kind: Utf8, operation_name: Utf8", stale)
+}
+
+// readNames reads all rows of names table, it's small as it keeps distinct names only
+func (r *archiveRetention) readNames(ctx context.Context, tableName string, cols ...string) ([][]string, error) {
+	var rows [][]string
+	err := r.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		rows = nil
+		res, err := session.StreamReadTable(ctx, r.opts.DBPath.FullTable(tableName), options.ReadColumns(cols...))
+		if err != nil {
+			if db.IsPathNotExistError(err) {
+				return nil
+			}
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+		for res.NextResultSet(ctx, cols...) {
+			for res.NextRow() {
+				row := make([]string, len(cols))
+				targets := make([]indexed.Required, len(cols))
+				for i := range row {
+					targets[i] = &row[i]
+				}
+				if err = res.ScanWithDefaults(targets...); err != nil {
+					return fmt.Errorf("names scan failed: %w", err)
+				}
+				rows = append(rows, row)
+			}
+		}
+		return res.Err()
+	})
+	return rows, err
+}
+
+func (r *archiveRetention) indexExists(ctx context.Context, tableName string, hashes []types.Value) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+	query := fmt.Sprintf(`DECLARE $hashes AS List<Uint64>;
+SELECT 1 FROM `+"`%s`"+` WHERE idx_hash IN $hashes LIMIT 1;`, r.opts.DBPath.FullTable(tableName))
+	var found bool
+	err := r.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		_, res, err := session.Execute(ctx, txc, query, table.NewQueryParameters(
+			table.ValueParam("$hashes", types.ListValue(hashes...)),
+		))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+		found = res.NextResultSet(ctx) && res.NextRow()
+		return res.Err()
+	})
+	return found, err
+}
+
+func (r *archiveRetention) deleteNames(ctx context.Context, tableName, fields string, keys []types.Value) error {
+	r.report(tableName, int64(len(keys)))
+	if r.opts.ArchiveDryRun || len(keys) == 0 {
+		return nil
+	}
+	query := fmt.Sprintf(`DECLARE $keys AS List<Struct<%s>>;
+DELETE FROM `+"`%s`"+` ON SELECT * FROM AS_TABLE($keys);`, fields, r.opts.DBPath.FullTable(tableName))
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > r.opts.ArchiveBatchSize {
+			n = r.opts.ArchiveBatchSize
+		}
+		if err := r.deleteRows(ctx, tableName, query, keys[:n]); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
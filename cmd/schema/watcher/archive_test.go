@@ -0,0 +1,54 @@
+package watcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+)
+
+func TestArchiveTables(t *testing.T) {
+	covered := make(map[string]bool, len(archiveTables))
+	for _, tbl := range archiveTables {
+		covered[tbl.name] = true
+	}
+	for name := range schema.Tables {
+		// names have no timestamps, they are deleted when no index entries are left
+		if strings.HasPrefix(name, "archive") && name != tblArchiveServiceNames && name != tblArchiveOperationNames {
+			assert.True(t, covered[name], "archive table %s has no retention", name)
+		}
+	}
+	assert.Equal(t, "archive", archiveTables[len(archiveTables)-1].name, "spans must be deleted after indexes")
+}
+
+func TestArchiveTable_Columns(t *testing.T) {
+	for _, tbl := range archiveTables {
+		switch tbl.name {
+		case "archive":
+			assert.Equal(t, []string{"trace_id_low", "trace_id_high", "span_id", "start_time"}, tbl.columns())
+		case "archive_idx_duration":
+			assert.Equal(t, []string{"idx_hash", "duration", "rev_start_time", "uniq"}, tbl.columns())
+		}
+	}
+}
+
+func TestArchiveTable_Expired(t *testing.T) {
+	spans := archiveTables[len(archiveTables)-1]
+	assert.True(t, spans.expired(99, 100))
+	assert.False(t, spans.expired(100, 100))
+
+	idx := archiveTables[0]
+	assert.True(t, idx.expired(-99, 100))
+	assert.False(t, idx.expired(-101, 100))
+}
+
+func TestArchiveTable_DeleteQuery(t *testing.T) {
+	dbPath := schema.DbPath{Path: "/local", Folder: "jaeger"}
+	tbl := archiveTable{"archive", []archiveColumn{{"trace_id_low", "Uint64"}, {"span_id", "Uint64"}}, "start_time"}
+
+	q := tbl.deleteQuery(dbPath)
+	assert.Contains(t, q, "DECLARE $keys AS List<Struct<trace_id_low: Uint64, span_id: Uint64>>;")
+	assert.Contains(t, q, "DELETE FROM `/local/jaeger/archive` ON SELECT * FROM AS_TABLE($keys);")
+}
@@ -0,0 +1,312 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/reader"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/sampling"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer"
+)
+
+const (
+	tblTraces = "traces"
+	// autoArchiveTimeout limits copying of single partition
+	autoArchiveTimeout = time.Hour
+)
+
+// autoArchiver copies traces matching rules from expired partition to archive storage,
+// before watcher marks partition for deletion
+type autoArchiver struct {
+	sessionProvider table.Client
+	opts            Options
+	logger          *zap.Logger
+	mf              metrics.Factory
+	rules           []sampling.Policy
+	reader          *reader.SpanReader
+
+	// archived keeps expired partitions which are copied already or given up
+	archived map[string]struct{}
+	// attempts counts failed copies of partition
+	attempts map[string]int
+	mu       sync.Mutex
+
+	traces    map[string]metrics.Counter
+	errors    metrics.Counter
+	abandoned metrics.Counter
+}
+
+func newAutoArchiver(sp table.Client, opts Options, mf metrics.Factory, logger *zap.Logger) (*autoArchiver, error) {
+	a := &autoArchiver{
+		sessionProvider: sp,
+		opts:            opts,
+		logger:          logger,
+		mf:              mf.Namespace(metrics.NSOptions{Name: "auto_archive"}),
+		archived:        make(map[string]struct{}),
+		attempts:        make(map[string]int),
+		traces:          make(map[string]metrics.Counter, len(opts.AutoArchiveRules)),
+	}
+	for _, cfg := range opts.AutoArchiveRules {
+		rule, err := sampling.NewPolicy(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("auto archive rule: %w", err)
+		}
+		a.rules = append(a.rules, rule)
+		a.traces[rule.Name()] = a.mf.Counter(metrics.Options{Name: "traces", Tags: map[string]string{"rule": rule.Name()}})
+	}
+	a.errors = a.mf.Counter(metrics.Options{Name: "errors"})
+	a.abandoned = a.mf.Counter(metrics.Options{Name: "abandoned_partitions"})
+	// trace is read from all active partitions, its spans may be in the next one
+	a.reader = reader.NewSpanReader(sp, reader.SpanReaderOptions{
+		DbPath:        opts.DBPath,
+		ReadTimeout:   operationTimeout,
+		QueryParallel: 16,
+		// archived copy keeps OTLP fields of spans
+		Otlp: true,
+	}, logger, hclog.NewNullLogger())
+	return a, nil
+}
+
+// Archived reports if partition can be marked for deletion
+func (a *autoArchiver) Archived(part schema.PartitionKey) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.archived[part.Suffix()]
+	return ok
+}
+
+func (a *autoArchiver) markArchived(part schema.PartitionKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.archived[part.Suffix()] = struct{}{}
+	delete(a.attempts, part.Suffix())
+}
+
+// failed counts failed attempt, partition is given up after AutoArchiveMaxAttempts, so retention isn't blocked forever
+func (a *autoArchiver) failed(part schema.PartitionKey) {
+	a.errors.Inc(1)
+	a.mu.Lock()
+	a.attempts[part.Suffix()]++
+	giveUp := a.attempts[part.Suffix()] >= a.opts.AutoArchiveMaxAttempts
+	a.mu.Unlock()
+	if giveUp {
+		a.abandoned.Inc(1)
+		a.logger.Error("auto archive partition abandoned, it will be deleted",
+			zap.String("suffix", part.Suffix()), zap.Int("attempts", a.opts.AutoArchiveMaxAttempts))
+		a.markArchived(part)
+	}
+}
+
+// Run copies traces of active partitions which expire before expireTime
+func (a *autoArchiver) Run(expireTime time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	parts, err := a.expiredPartitions(ctx, expireTime)
+	cancel()
+	if err != nil {
+		a.errors.Inc(1)
+		a.logger.Error("auto archive partition list failed", zap.Error(err))
+		return
+	}
+	expired := make(map[string]struct{}, len(parts))
+	for _, part := range parts {
+		expired[part.Suffix()] = struct{}{}
+		if a.Archived(part) {
+			continue
+		}
+		a.logger.Info("auto archive partition", zap.String("suffix", part.Suffix()))
+		ctx, cancel := context.WithTimeout(context.Background(), autoArchiveTimeout)
+		n, err := a.archivePartition(ctx, part)
+		cancel()
+		if err != nil {
+			a.logger.Error("auto archive partition failed", zap.String("suffix", part.Suffix()), zap.Error(err))
+			a.failed(part)
+			continue
+		}
+		a.logger.Info("partition auto archived", zap.String("suffix", part.Suffix()), zap.Int("traces", n))
+		a.markArchived(part)
+	}
+	// forget partitions which are marked for deletion already
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for suffix := range a.archived {
+		if _, ok := expired[suffix]; !ok {
+			delete(a.archived, suffix)
+		}
+	}
+}
+
+func (a *autoArchiver) expiredPartitions(ctx context.Context, expireTime time.Time) ([]schema.PartitionKey, error) {
+	var result []schema.PartitionKey
+	err := a.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		result = nil
+		_, res, err := session.Execute(ctx, txc, schema.BuildQuery(a.opts.DBPath, schema.QueryActiveParts), nil)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				part := schema.PartitionKey{}
+				if err = res.ScanWithDefaults(&part.Date, &part.Num, &part.IsActive); err != nil {
+					return fmt.Errorf("part scan err: %w", err)
+				}
+				if _, t := part.TimeSpan(); expireTime.Sub(t) > 0 {
+					result = append(result, part)
+				}
+			}
+		}
+		return res.Err()
+	})
+	return result, err
+}
+
+// archivePartition writes matched traces with archive writer, so they are indexed in archive the same way
+func (a *autoArchiver) archivePartition(ctx context.Context, part schema.PartitionKey) (int, error) {
+	ids, matched, err := a.matchTraces(ctx, part)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	w := writer.NewSpanWriter(a.sessionProvider, a.mf, a.logger, hclog.NewNullLogger(), writer.SpanWriterOptions{
+		ArchiveWriter:     true,
+		BufferSize:        1000,
+		BatchSize:         100,
+		BatchWorkers:      1,
+		IndexerBufferSize: 1000,
+		IndexerMaxTraces:  100,
+		IndexerTTL:        time.Second * 5,
+		DbPath:            a.opts.DBPath,
+		WriteTimeout:      operationTimeout,
+		OpCacheSize:       256,
+		Overflow:          batch.OverflowBlock,
+		OverflowTimeout:   operationTimeout,
+	})
+	err = a.copyTraces(ctx, w, ids)
+	// batch write errors are only logged by writer, so spans lost by them are checked as well
+	if lost := w.Close(ctx).Lost(); err == nil && lost > 0 {
+		err = fmt.Errorf("%d spans or index entries aren't written", lost)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for rule, n := range matched {
+		a.traces[rule].Inc(n)
+	}
+	return len(ids), nil
+}
+
+func (a *autoArchiver) copyTraces(ctx context.Context, w *writer.SpanWriter, ids []model.TraceID) error {
+	for _, id := range ids {
+		trace, err := a.reader.GetTrace(ctx, id)
+		if errors.Is(err, reader.ErrTraceNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read trace %s: %w", id, err)
+		}
+		for _, span := range trace.Spans {
+			if err = w.WriteSpan(ctx, span); err != nil {
+				return fmt.Errorf("archive trace %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchTraces scans traces table of partition, spans of the same trace come together as rows are ordered by trace id.
+// Number of matched traces is returned per rule.
+func (a *autoArchiver) matchTraces(ctx context.Context, part schema.PartitionKey) ([]model.TraceID, map[string]int64, error) {
+	fullName := part.BuildFullTableName(a.opts.DBPath.String(), tblTraces)
+	var ids []model.TraceID
+	matched := make(map[string]int64)
+	err := a.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
+		// start over on retry
+		ids = nil
+		matched = make(map[string]int64)
+		res, err := session.StreamReadTable(ctx, fullName,
+			options.ReadOrdered(),
+			options.ReadColumns("trace_id_low", "trace_id_high", "span_id", "operation_name", "flags", "start_time", "duration", "extra"),
+		)
+		if err != nil {
+			if db.IsPathNotExistError(err) {
+				return nil
+			}
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+
+		var spans []*model.Span
+		processTrace := func() {
+			if len(spans) == 0 {
+				return
+			}
+			if rule := a.match(spans); rule != "" {
+				ids = append(ids, spans[0].TraceID)
+				matched[rule]++
+			}
+			spans = spans[:0]
+		}
+		for res.NextResultSet(ctx, "trace_id_low", "trace_id_high", "span_id", "operation_name", "flags", "start_time", "duration", "extra") {
+			for res.NextRow() {
+				dbSpan := dbmodel.Span{}
+				err = res.ScanWithDefaults(
+					&dbSpan.TraceIDLow,
+					&dbSpan.TraceIDHigh,
+					&dbSpan.SpanID,
+					&dbSpan.OperationName,
+					&dbSpan.Flags,
+					&dbSpan.StartTime,
+					&dbSpan.Duration,
+					&dbSpan.Extra,
+				)
+				if err != nil {
+					return fmt.Errorf("span.Scan failed: %w", err)
+				}
+				span, err := dbmodel.ToDomain(&dbSpan)
+				if err != nil {
+					return err
+				}
+				if len(spans) > 0 && spans[0].TraceID != span.TraceID {
+					processTrace()
+				}
+				spans = append(spans, span)
+			}
+		}
+		if err = res.Err(); err != nil {
+			return err
+		}
+		processTrace()
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ids, matched, nil
+}
+
+// match returns name of the first rule matching trace
+func (a *autoArchiver) match(trace []*model.Span) string {
+	for _, rule := range a.rules {
+		if rule.Match(trace) {
+			return rule.Name()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/sampling"
+)
+
+func TestAutoArchiver_Match(t *testing.T) {
+	a, err := newAutoArchiver(nil, Options{AutoArchiveRules: []sampling.PolicyConfig{
+		{Name: "errors", Type: sampling.PolicyError},
+		{Name: "slow", Type: sampling.PolicyLatency, Threshold: time.Second},
+		{Name: "vip", Type: sampling.PolicyTag, Key: "customer", Value: "vip"},
+		{Name: "debug", Type: sampling.PolicyDebug},
+	}}, metrics.NullFactory, zap.NewNop())
+	require.NoError(t, err)
+
+	process := model.NewProcess("svc", nil)
+	assert.Equal(t, "", a.match([]*model.Span{{Process: process, Duration: time.Millisecond}}))
+	assert.Equal(t, "errors", a.match([]*model.Span{
+		{Process: process},
+		{Process: process, Duration: 2 * time.Second, Tags: []model.KeyValue{model.Bool("error", true)}},
+	}))
+	assert.Equal(t, "slow", a.match([]*model.Span{{Process: process, Duration: 2 * time.Second}}))
+	assert.Equal(t, "vip", a.match([]*model.Span{{Process: process, Tags: []model.KeyValue{model.String("customer", "vip")}}}))
+	assert.Equal(t, "debug", a.match([]*model.Span{{Process: process, Flags: model.DebugFlag}}))
+
+	part := schema.PartitionFromTime(time.Now())
+	assert.False(t, a.Archived(part))
+	a.archived[part.Suffix()] = struct{}{}
+	assert.True(t, a.Archived(part))
+
+	_, err = newAutoArchiver(nil, Options{AutoArchiveRules: []sampling.PolicyConfig{{Type: "unknown"}}}, metrics.NullFactory, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestAutoArchiver_Failed(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	a, err := newAutoArchiver(nil, Options{
+		AutoArchiveRules:       []sampling.PolicyConfig{{Type: sampling.PolicyError}},
+		AutoArchiveMaxAttempts: 2,
+	}, mf, zap.NewNop())
+	require.NoError(t, err)
+
+	part := schema.PartitionFromTime(time.Now())
+	a.failed(part)
+	assert.False(t, a.Archived(part))
+	a.failed(part)
+	assert.True(t, a.Archived(part), "partition is deleted after max attempts")
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "auto_archive.errors", Value: 2},
+		metricstest.ExpectedMetric{Name: "auto_archive.abandoned_partitions", Value: 1},
+	)
+}
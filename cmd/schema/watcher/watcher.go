@@ -6,12 +6,15 @@ import (
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/spf13/viper"
+	"github.com/uber/jaeger-lib/metrics"
 	ydb "github.com/ydb-platform/ydb-go-sdk/v3"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
 	"go.uber.org/zap"
 
 	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
 	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/sampling"
 )
 
 const (
@@ -24,6 +27,20 @@ type Options struct {
 	Expiration time.Duration
 	Lookahead  time.Duration
 	DBPath     schema.DbPath
+
+	// ArchiveRetention enables deletion of archived spans older than this value
+	ArchiveRetention time.Duration
+	// ArchiveBatchSize is the maximum number of rows deleted by single query
+	ArchiveBatchSize int
+	// ArchiveDryRun only counts expired archive rows
+	ArchiveDryRun bool
+	// ArchiveInterval is the period of archive tables scan for expired rows
+	ArchiveInterval time.Duration
+
+	// AutoArchiveRules enable copying of matching traces to archive storage before partition is deleted
+	AutoArchiveRules []sampling.PolicyConfig
+	// AutoArchiveMaxAttempts is the number of failed copies after which partition is deleted anyway
+	AutoArchiveMaxAttempts int
 }
 
 type Watcher struct {
@@ -31,20 +48,35 @@ type Watcher struct {
 	opts            Options
 	logger          *zap.Logger
 
-	ticker           *time.Ticker
-	tableDefinitions map[string]partDefinition
-	knownTables      *lru.Cache
+	ticker      *time.Ticker
+	partitions  *schema.PartitionCreator
+	knownTables *lru.Cache
+	archive     *archiveRetention
+	autoArchive *autoArchiver
 }
 
-func NewWatcher(opts Options, sp table.Client, logger *zap.Logger) *Watcher {
-	return &Watcher{
+func NewWatcher(opts Options, sp table.Client, mf metrics.Factory, logger *zap.Logger) (*Watcher, error) {
+	w := &Watcher{
 		sessionProvider: sp,
 		opts:            opts,
 		logger:          logger,
 
-		tableDefinitions: definitions(),
-		knownTables:      mustNewLRU(500),
+		partitions: schema.NewPartitionCreator(sp, schema.PartitionCreatorOptions{
+			DbPath: opts.DBPath,
+			Counts: schema.PartitionCounts(viper.GetViper()),
+		}),
+		knownTables: mustNewLRU(500),
+	}
+	if opts.ArchiveRetention > 0 {
+		w.archive = newArchiveRetention(sp, opts, mf, logger)
+	}
+	if len(opts.AutoArchiveRules) > 0 {
+		var err error
+		if w.autoArchive, err = newAutoArchiver(sp, opts, mf, logger); err != nil {
+			return nil, err
+		}
 	}
+	return w, nil
 }
 
 func (w *Watcher) Run(interval time.Duration) {
@@ -55,6 +87,30 @@ func (w *Watcher) Run(interval time.Duration) {
 			w.once()
 		}
 	}()
+	if w.archive != nil {
+		// archive deletion scans whole tables, so it runs apart from creation of partitions and less often
+		archiveInterval := w.opts.ArchiveInterval
+		if archiveInterval <= 0 {
+			archiveInterval = interval
+		}
+		go func() {
+			ticker := time.NewTicker(archiveInterval)
+			w.archive.once(context.Background())
+			for range ticker.C {
+				w.archive.once(context.Background())
+			}
+		}()
+	}
+	if w.autoArchive != nil {
+		// partitions are kept until they are copied, so copying doesn't delay their creation and deletion
+		go func() {
+			ticker := time.NewTicker(interval)
+			w.autoArchive.Run(time.Now().Add(-w.opts.Expiration))
+			for range ticker.C {
+				w.autoArchive.Run(time.Now().Add(-w.opts.Expiration))
+			}
+		}()
+	}
 }
 
 func (w *Watcher) once() {
@@ -94,15 +150,8 @@ func (w *Watcher) createTables() error {
 	parts := schema.MakePartitionList(t, t.Add(w.opts.Lookahead))
 	for _, part := range parts {
 		w.logger.Info("creating partition", zap.String("suffix", part.Suffix()))
-		if err := w.createTablesForPartition(ctx, part); err != nil {
-			return err
-		}
-		err := w.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
-			_, _, err := session.Execute(ctx, txc, schema.BuildQuery(w.opts.DBPath, schema.InsertPart), part.QueryParams())
-			return err
-		})
-		if err != nil {
-			w.logger.Error("partition save failed",
+		if err := w.partitions.Create(ctx, part); err != nil {
+			w.logger.Error("create partition failed",
 				zap.String("suffix", part.Suffix()), zap.Error(err),
 			)
 			return err
@@ -111,28 +160,6 @@ func (w *Watcher) createTables() error {
 	return nil
 }
 
-func (w *Watcher) createTablesForPartition(ctx context.Context, part schema.PartitionKey) error {
-	for name, def := range w.tableDefinitions {
-		fullName := part.BuildFullTableName(w.opts.DBPath.String(), name)
-		if w.tableKnown(ctx, fullName) {
-			// We already created this table, skip
-			continue
-		}
-		err := w.sessionProvider.Do(ctx, func(ctx context.Context, session table.Session) error {
-			return session.CreateTable(ctx, fullName, def.defFunc(def.count)...)
-		})
-		if err != nil {
-			w.logger.Error("create table failed",
-				zap.String("name", fullName), zap.Error(err),
-			)
-			return err
-		}
-		// save knowledge about table for later
-		w.knownTables.Add(fullName, struct{}{})
-	}
-	return nil
-}
-
 func (w *Watcher) dropOldTables() {
 	expireTime := time.Now().Add(-w.opts.Expiration)
 	w.logger.Info("delete old tables", zap.Time("before", expireTime))
@@ -157,6 +184,10 @@ func (w *Watcher) dropOldTables() {
 				_, t := part.TimeSpan()
 				if expireTime.Sub(t) > 0 {
 					if part.IsActive {
+						if w.autoArchive != nil && !w.autoArchive.Archived(part) {
+							// copied to archive storage by the next run
+							continue
+						}
 						err := w.markPartitionForDelete(ctx, session, part)
 						if err != nil {
 							w.logger.Error("update partition failed", zap.String("suffix", part.Suffix()), zap.Error(err))
@@ -187,7 +218,7 @@ func (w *Watcher) dropTables(ctx context.Context, session table.Session, k schem
 			switch {
 			// table or path already removed, ignore err
 			case opErr != nil && db.IssueContainsMessage(err, "EPathStateNotExist"):
-			case ydb.IsOperationErrorSchemeError(err) && db.IssueContainsMessage(err, "Path does not exist"):
+			case db.IsPathNotExistError(err):
 			default:
 				w.logger.Error("drop table failed", zap.String("table", fullName), zap.Error(err))
 				return err
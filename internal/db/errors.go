@@ -5,6 +5,7 @@ import (
 
 	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
 	"github.com/ydb-platform/ydb-go-sdk/v3"
+	grpcCodes "google.golang.org/grpc/codes"
 )
 
 func IssueContainsMessage(err error, search string) bool {
@@ -16,3 +17,47 @@ func IssueContainsMessage(err error, search string) bool {
 	})
 	return result
 }
+
+// requestSizeIssues are parts of issue messages YDB server reports when request, e.g. BulkUpsert batch,
+// is over its size limits
+var requestSizeIssues = []string{"too big", "too large", "exceeds limit", "message larger than max"}
+
+// IsRequestSizeError checks if request was rejected because it exceeds gRPC message size limit of client or YDB server,
+// e.g. "grpc: received message larger than max", or YDB server rejected the operation as too large.
+// Such requests fail on every retry, they should be split instead.
+func IsRequestSizeError(err error) bool {
+	if ydb.IsTransportError(err, grpcCodes.ResourceExhausted) && strings.Contains(err.Error(), "message larger than max") {
+		return true
+	}
+	if !ydb.IsOperationError(err) {
+		return false
+	}
+	var messages []string
+	ydb.IterateByIssues(err, func(message string, code Ydb.StatusIds_StatusCode, severity uint32) {
+		messages = append(messages, message)
+	})
+	return isRequestSizeOperation(Ydb.StatusIds_StatusCode(ydb.OperationError(err).Code()), messages)
+}
+
+// isRequestSizeOperation checks status code and issue messages of operation error
+func isRequestSizeOperation(code Ydb.StatusIds_StatusCode, messages []string) bool {
+	switch code {
+	case Ydb.StatusIds_BAD_REQUEST, Ydb.StatusIds_PRECONDITION_FAILED, Ydb.StatusIds_GENERIC_ERROR:
+	default:
+		return false
+	}
+	for _, msg := range messages {
+		msg = strings.ToLower(msg)
+		for _, issue := range requestSizeIssues {
+			if strings.Contains(msg, issue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsPathNotExistError checks if operation failed because table or directory doesn't exist
+func IsPathNotExistError(err error) bool {
+	return ydb.IsOperationErrorSchemeError(err) && IssueContainsMessage(err, "Path does not exist")
+}
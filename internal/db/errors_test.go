@@ -0,0 +1,40 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsRequestSizeError(t *testing.T) {
+	assert.True(t, IsRequestSizeError(status.Error(codes.ResourceExhausted, "grpc: received message larger than max (70000000 vs. 67108864)")))
+	assert.True(t, IsRequestSizeError(status.Error(codes.ResourceExhausted, "grpc: trying to send message larger than max (70000000 vs. 67108864)")))
+	assert.False(t, IsRequestSizeError(status.Error(codes.ResourceExhausted, "overloaded")))
+	assert.False(t, IsRequestSizeError(status.Error(codes.InvalidArgument, "grpc: received message larger than max")))
+	assert.False(t, IsRequestSizeError(errors.New("message larger than max")))
+}
+
+func TestIsRequestSizeOperation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		code     Ydb.StatusIds_StatusCode
+		messages []string
+		expected bool
+	}{
+		{"bad request", Ydb.StatusIds_BAD_REQUEST, []string{"Request is too big: 70000000 bytes"}, true},
+		{"precondition failed", Ydb.StatusIds_PRECONDITION_FAILED, []string{"Batch size exceeds limit"}, true},
+		{"generic error", Ydb.StatusIds_GENERIC_ERROR, []string{"Bulk upsert failed", "Row batch is too large"}, true},
+		{"grpc message size", Ydb.StatusIds_BAD_REQUEST, []string{"grpc: received message larger than max"}, true},
+		{"other issue", Ydb.StatusIds_BAD_REQUEST, []string{"Unknown column 'foo'"}, false},
+		{"no issues", Ydb.StatusIds_BAD_REQUEST, nil, false},
+		{"overloaded", Ydb.StatusIds_OVERLOADED, []string{"Request is too big"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isRequestSizeOperation(tc.code, tc.messages))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const secondaryPrefix = "ydb.secondary."
+
+var (
+	secondaryLocationKeys = []string{KeyYdbAddress, KeyYdbPath, KeyYdbFolder, KeyYdbSecureConnection, KeyYdbCAFile}
+	// credentials are inherited as a whole, otherwise mixed primary and secondary ones would conflict
+	secondaryCredentialsKeys = []string{
+		keyYdbAnonymous, KeyYdbToken, KeyYdbSaMetaAuth, keyYdbSaKeyJson,
+		KeyYdbSaPrivateKeyFile, KeyYdbSaId, KeyYdbSaKeyID,
+	}
+)
+
+func secondaryKey(key string) string {
+	return secondaryPrefix + strings.TrimPrefix(key, "ydb.")
+}
+
+// SecondaryViper returns viper with connection settings of secondary database at primary keys,
+// so it can be passed to DialFromViper. Keys which aren't set for secondary database are taken from primary one,
+// credentials are taken from primary database only if none of them is set for secondary.
+func SecondaryViper(v *viper.Viper) *viper.Viper {
+	res := viper.New()
+	for _, key := range secondaryLocationKeys {
+		if v.IsSet(secondaryKey(key)) {
+			res.Set(key, v.Get(secondaryKey(key)))
+		} else if v.IsSet(key) {
+			res.Set(key, v.Get(key))
+		}
+	}
+	from := func(key string) string { return key }
+	for _, key := range secondaryCredentialsKeys {
+		if v.IsSet(secondaryKey(key)) {
+			from = secondaryKey
+			break
+		}
+	}
+	for _, key := range secondaryCredentialsKeys {
+		if v.IsSet(from(key)) {
+			res.Set(key, v.Get(from(key)))
+		}
+	}
+	if v.IsSet(KeyIAMEndpoint) {
+		res.Set(KeyIAMEndpoint, v.Get(KeyIAMEndpoint))
+	}
+	return res
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecondaryViper(t *testing.T) {
+	t.Run("inherit", func(t *testing.T) {
+		v := viper.New()
+		v.Set(KeyYdbAddress, "primary:2135")
+		v.Set(KeyYdbPath, "/primary")
+		v.Set(KeyYdbFolder, "jaeger")
+		v.Set(KeyYdbToken, "token")
+		v.Set(KeyYdbSecondaryAddress, "secondary:2135")
+		v.Set(KeyYdbSecondaryPath, "/secondary")
+
+		res := SecondaryViper(v)
+		assert.Equal(t, "secondary:2135", res.GetString(KeyYdbAddress))
+		assert.Equal(t, "/secondary", res.GetString(KeyYdbPath))
+		assert.Equal(t, "jaeger", res.GetString(KeyYdbFolder))
+		assert.Equal(t, "token", res.GetString(KeyYdbToken))
+	})
+	t.Run("own credentials", func(t *testing.T) {
+		v := viper.New()
+		v.Set(KeyYdbToken, "token")
+		v.Set(secondaryKey(keyYdbAnonymous), true)
+
+		res := SecondaryViper(v)
+		assert.True(t, res.GetBool(keyYdbAnonymous))
+		assert.Empty(t, res.GetString(KeyYdbToken))
+	})
+}
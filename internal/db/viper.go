@@ -21,6 +21,12 @@ const (
 
 	KeyYdbCAFile = "ydb.ca-file"
 
+	// KeyYdbSecondaryAddress enables dual-write of spans to secondary database, e.g. during migration between clusters.
+	// Connection keys under ydb.secondary override primary ones, see SecondaryViper.
+	KeyYdbSecondaryAddress = "ydb.secondary.address"
+	KeyYdbSecondaryPath    = "ydb.secondary.path"
+	KeyYdbSecondaryFolder  = "ydb.secondary.folder"
+
 	KeyYdbConnectTimeout      = "ydb.connect-timeout"
 	KeyYdbWriteTimeout        = "ydb.write-timeout"
 	KeyYdbRetryAttemptTimeout = "ydb.retry-attempt-timeout"
@@ -37,19 +43,108 @@ const (
 	KeyYdbWriterBufferSize   = "ydb.writer.buffer-size"
 	KeyYdbWriterBatchSize    = "ydb.writer.batch-size"
 	KeyYdbWriterBatchWorkers = "ydb.writer.batch-workers"
+	// KeyYdbWriterBatchBytes limits estimated size of span batch, batch is written when either size or item count is reached
+	KeyYdbWriterBatchBytes    = "ydb.writer.batch-bytes"
+	KeyYdbWriterFlushInterval = "ydb.writer.flush-interval"
 	// KeyYdbWriterMaxSpanAge controls max age for accepted spans.
 	// Each span older than time.Now() - KeyYdbWriterMaxSpanAge will be neglected.
 	// Defaults to zero which effectively means any span age is good.
 	KeyYdbWriterMaxSpanAge     = "ydb.writer.max-span-age"
 	KeyYdbWriterSvcOpCacheSize = "ydb.writer.service-name-operation-cache-size"
+	// KeyYdbWriterNamesFlushInterval is the period of background service and operation names registration
+	KeyYdbWriterNamesFlushInterval = "ydb.writer.names.flush-interval"
+	// KeyYdbWriterMaxFutureSkew controls max distance of span start time into the future.
+	// Such spans are rejected or clamped to receive time according to KeyYdbWriterFutureSkewPolicy.
+	// Defaults to zero which disables the check.
+	KeyYdbWriterMaxFutureSkew    = "ydb.writer.max-future-skew"
+	KeyYdbWriterFutureSkewPolicy = "ydb.writer.future-skew-policy"
+	// KeyYdbWriterStreamingEnabled exposes streaming span writer, so collector sends spans over long-lived streams
+	// instead of making RPC per span
+	KeyYdbWriterStreamingEnabled = "ydb.writer.streaming.enabled"
+	// KeyYdbWriterShutdownTimeout limits time spent on flushing buffered spans and index entries on shutdown
+	KeyYdbWriterShutdownTimeout = "ydb.writer.shutdown-timeout"
+
+	// KeyYdbWriterPartitionsCreate enables creation of partitions missing at write time, e.g. for late spans
+	// or before watcher has run. Partitions ending earlier than KeyYdbWriterPartitionsMaxAge ago or starting later than
+	// KeyYdbWriterPartitionsMaxAhead from now aren't created. Tables use the same parts_* settings as watcher.
+	KeyYdbWriterPartitionsCreate   = "ydb.writer.partitions.create"
+	KeyYdbWriterPartitionsMaxAge   = "ydb.writer.partitions.max-age"
+	KeyYdbWriterPartitionsMaxAhead = "ydb.writer.partitions.max-ahead"
+
+	// KeyYdbWriterDependenciesEnabled turns on in-memory aggregation of service dependency links
+	// which are flushed to dependencies table every KeyYdbWriterDependenciesWindow.
+	KeyYdbWriterDependenciesEnabled  = "ydb.writer.dependencies.enabled"
+	KeyYdbWriterDependenciesWindow   = "ydb.writer.dependencies.window"
+	KeyYdbWriterDependenciesMaxSpans = "ydb.writer.dependencies.max-spans"
+	KeyYdbWriterDependenciesMaxLinks = "ydb.writer.dependencies.max-links"
+
+	// KeyYdbWriterDedupWindow enables filter which skips indexing of spans with trace id, span id and service
+	// seen within last window. With KeyYdbWriterDedupSpans duplicate spans aren't written at all.
+	KeyYdbWriterDedupWindow   = "ydb.writer.dedup.window"
+	KeyYdbWriterDedupMaxSpans = "ydb.writer.dedup.max-spans"
+	KeyYdbWriterDedupSpans    = "ydb.writer.dedup.spans"
+
+	// KeyYdbWriterSamplingEnabled turns on tail-based sampling, spans are buffered per trace
+	// for KeyYdbWriterSamplingDecisionWait and whole trace is kept if any of KeyYdbWriterSamplingPolicies matches.
+	KeyYdbWriterSamplingEnabled           = "ydb.writer.sampling.enabled"
+	KeyYdbWriterSamplingDecisionWait      = "ydb.writer.sampling.decision-wait"
+	KeyYdbWriterSamplingMaxTraces         = "ydb.writer.sampling.max-traces"
+	KeyYdbWriterSamplingDecisionCacheSize = "ydb.writer.sampling.decision-cache-size"
+	KeyYdbWriterSamplingPolicies          = "ydb.writer.sampling.policies"
+
+	// KeyYdbWriterRateLimitEnabled turns on per-service token buckets, spans over the rate are dropped.
+	// KeyYdbWriterRateLimitOverrides is a list of per-service limits, see ratelimit.LimitConfig.
+	KeyYdbWriterRateLimitEnabled        = "ydb.writer.rate-limit.enabled"
+	KeyYdbWriterRateLimitSpansPerSecond = "ydb.writer.rate-limit.spans-per-second"
+	KeyYdbWriterRateLimitBytesPerSecond = "ydb.writer.rate-limit.bytes-per-second"
+	KeyYdbWriterRateLimitBurst          = "ydb.writer.rate-limit.burst"
+	KeyYdbWriterRateLimitOverrides      = "ydb.writer.rate-limit.overrides"
+
+	// KeyYdbWriterRedactionRules is a list of rules applied to tags and log fields before spans are saved,
+	// see redaction.RuleConfig.
+	KeyYdbWriterRedactionRules    = "ydb.writer.redaction.rules"
+	KeyYdbWriterRedactionHashSalt = "ydb.writer.redaction.hash-salt"
+
+	// KeyYdbWriterLimitsMaxTagValueLength and other limits truncate oversized spans before they are saved,
+	// span warnings record what was cut. Zero means no limit.
+	KeyYdbWriterLimitsMaxTagValueLength = "ydb.writer.limits.max-tag-value-length"
+	KeyYdbWriterLimitsMaxTags           = "ydb.writer.limits.max-tags"
+	KeyYdbWriterLimitsMaxLogs           = "ydb.writer.limits.max-logs"
+	KeyYdbWriterLimitsMaxSpanSize       = "ydb.writer.limits.max-span-size"
+
+	// KeyYdbWriterOverflowPolicy is one of drop, block or reject, see batch.OverflowPolicy.
+	// Archive writer has its own policy.
+	KeyYdbWriterOverflowPolicy         = "ydb.writer.overflow-policy"
+	KeyYdbWriterOverflowTimeout        = "ydb.writer.overflow-timeout"
+	KeyYdbWriterArchiveOverflowPolicy  = "ydb.writer.archive.overflow-policy"
+	KeyYdbWriterArchiveOverflowTimeout = "ydb.writer.archive.overflow-timeout"
+
+	KeyYdbWriterRetryMaxAttempts     = "ydb.writer.retry.max-attempts"
+	KeyYdbWriterRetryInitialInterval = "ydb.writer.retry.initial-interval"
+	KeyYdbWriterRetryMaxInterval     = "ydb.writer.retry.max-interval"
+	KeyYdbWriterRetryQueueSize       = "ydb.writer.retry.queue-size"
+
+	// KeyYdbWriterSpoolDir enables on-disk spool for spans which can't be written to db right now.
+	// Primary and archive writers use separate subdirectories.
+	KeyYdbWriterSpoolDir          = "ydb.writer.spool.dir"
+	KeyYdbWriterSpoolMaxSize      = "ydb.writer.spool.max-size"
+	KeyYdbWriterSpoolSegmentSize  = "ydb.writer.spool.segment-size"
+	KeyYdbWriterSpoolSync         = "ydb.writer.spool.sync"
+	KeyYdbWriterSpoolSyncInterval = "ydb.writer.spool.sync-interval"
 
 	KeyYdbIndexerBufferSize = "ydb.indexer.buffer-size"
 	KeyYdbIndexerMaxTraces  = "ydb.indexer.max-traces"
 	KeyYdbIndexerMaxTTL     = "ydb.indexer.max-ttl"
+	// KeyYdbIndexerTagPolicyFile is YAML or JSON file with indexer.TagPolicyConfig,
+	// it's checked for changes every KeyYdbIndexerTagPolicyReloadInterval
+	KeyYdbIndexerTagPolicyFile           = "ydb.indexer.tag-policy.file"
+	KeyYdbIndexerTagPolicyReloadInterval = "ydb.indexer.tag-policy.reload-interval"
 
 	KeyYDBPartitionSize      = "ydb.partition-size"
 	KeyYDBFeatureSplitByLoad = "ydb.feature.split-by-load"
 	KeyYDBFeatureCompression = "ydb.feature.compression"
+	// KeyYDBDependenciesTTL is the retention of dependencies table rows, it's applied when table is created
+	KeyYDBDependenciesTTL = "ydb.dependencies-ttl"
 
 	KeyYdbLogScope = "ydb.log.scope"
 )
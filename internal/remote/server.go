@@ -0,0 +1,126 @@
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/jaegertracing/jaeger/plugin/storage/grpc/shared"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Options of standalone remote storage server
+type Options struct {
+	// Address is host:port to listen on
+	Address string
+	// TLS is enabled when certificate and key are set, client certificates are verified if client CA is set too
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	// ShutdownTimeout limits waiting for running calls on shutdown, they are cancelled after it
+	ShutdownTimeout time.Duration
+}
+
+// Server serves Jaeger remote storage gRPC API, so collectors and queries use storage over network
+// instead of starting plugin subprocess each
+type Server struct {
+	opts       Options
+	logger     hclog.Logger
+	grpcServer *grpc.Server
+	health     *health.Server
+}
+
+func NewServer(opts Options, services *shared.PluginServices, logger hclog.Logger) (*Server, error) {
+	var grpcOpts []grpc.ServerOption
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" || opts.TLSClientCAFile != "" {
+		tlsCfg, err := tlsConfig(opts)
+		if err != nil {
+			return nil, fmt.Errorf("tls config: %w", err)
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+	s := &Server{
+		opts:       opts,
+		logger:     logger,
+		grpcServer: grpc.NewServer(grpcOpts...),
+		health:     health.NewServer(),
+	}
+	handler := shared.NewGRPCHandlerWithPlugins(services.Store, services.ArchiveStore, services.StreamingSpanWriter)
+	if err := handler.Register(s.grpcServer); err != nil {
+		return nil, fmt.Errorf("register storage handler: %w", err)
+	}
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	return s, nil
+}
+
+func tlsConfig(opts Options) (*tls.Config, error) {
+	if opts.TLSCertFile == "" || opts.TLSKeyFile == "" {
+		return nil, errors.New("both certificate and key files must be set")
+	}
+	cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if opts.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(opts.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in client CA file '%s'", opts.TLSClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// ListenAndServe listens on Address and serves calls until Shutdown
+func (s *Server) ListenAndServe() error {
+	lis, err := net.Listen("tcp", s.opts.Address)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", s.opts.Address, err)
+	}
+	return s.Serve(lis)
+}
+
+// Serve serves calls on lis until Shutdown
+func (s *Server) Serve(lis net.Listener) error {
+	s.logger.Warn("serving remote storage", "addr", lis.Addr().String())
+	return s.grpcServer.Serve(lis)
+}
+
+// Shutdown reports server as not serving to health checks, stops accepting calls and waits
+// for running ones up to ShutdownTimeout
+func (s *Server) Shutdown() {
+	s.health.Shutdown()
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+	if s.opts.ShutdownTimeout <= 0 {
+		<-done
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(s.opts.ShutdownTimeout):
+		s.logger.Warn("remote storage shutdown timed out, cancelling running calls")
+		s.grpcServer.Stop()
+		<-done
+	}
+}
@@ -0,0 +1,156 @@
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/plugin/storage/grpc/shared"
+	"github.com/jaegertracing/jaeger/plugin/storage/memory"
+	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type memoryPlugin struct {
+	store *memory.Store
+}
+
+func (p memoryPlugin) SpanReader() spanstore.Reader             { return p.store }
+func (p memoryPlugin) SpanWriter() spanstore.Writer             { return p.store }
+func (p memoryPlugin) DependencyReader() dependencystore.Reader { return p.store }
+func (p memoryPlugin) ArchiveSpanReader() spanstore.Reader      { return p.store }
+func (p memoryPlugin) ArchiveSpanWriter() spanstore.Writer      { return p.store }
+func (p memoryPlugin) StreamingSpanWriter() spanstore.Writer    { return p.store }
+
+func startServer(t *testing.T, opts Options) (*Server, string, chan error) {
+	p := memoryPlugin{store: memory.NewStore()}
+	server, err := NewServer(opts, &shared.PluginServices{Store: p, ArchiveStore: p}, hclog.NewNullLogger())
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(lis)
+	}()
+	return server, lis.Addr().String(), errCh
+}
+
+func TestServer(t *testing.T) {
+	server, addr, errCh := startServer(t, Options{ShutdownTimeout: time.Second})
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	client := shared.NewGRPCClient(conn)
+	span := &model.Span{
+		TraceID:       model.NewTraceID(1, 2),
+		SpanID:        3,
+		OperationName: "op",
+		Process:       model.NewProcess("svc", nil),
+		StartTime:     time.Now().UTC(),
+	}
+	require.NoError(t, client.SpanWriter().WriteSpan(ctx, span))
+	services, err := client.SpanReader().GetServices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, services)
+	capabilities, err := client.Capabilities()
+	require.NoError(t, err)
+	assert.True(t, capabilities.ArchiveSpanReader)
+	assert.True(t, capabilities.ArchiveSpanWriter)
+
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+
+	server.Shutdown()
+	assert.NoError(t, <-errCh)
+}
+
+func TestServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, certPEM := writeCert(t, dir)
+	server, addr, errCh := startServer(t, Options{TLSCertFile: certFile, TLSKeyFile: keyFile})
+	defer func() {
+		server.Shutdown()
+		assert.NoError(t, <-errCh)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(certPEM))
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = shared.NewGRPCClient(conn).SpanReader().GetServices(ctx)
+	assert.NoError(t, err)
+
+	plainConn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer plainConn.Close()
+	_, err = shared.NewGRPCClient(plainConn).SpanReader().GetServices(ctx)
+	assert.Error(t, err)
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeCert(t, dir)
+
+	_, err := tlsConfig(Options{TLSCertFile: certFile})
+	assert.Error(t, err)
+
+	cfg, err := tlsConfig(Options{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: certFile})
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+
+	_, err = tlsConfig(Options{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: keyFile})
+	assert.Error(t, err)
+}
+
+// writeCert writes self-signed certificate for 127.0.0.1 and its key to dir
+func writeCert(t *testing.T, dir string) (string, string, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "jaeger-ydb-store"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile, certPEM
+}
@@ -1,6 +1,8 @@
 package viper
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -34,3 +36,21 @@ func ConfigureViperFromFlag(v *viper.Viper) {
 		}
 	}
 }
+
+// UnmarshalListKey reads list of structs from config file or json string set by env variable
+func UnmarshalListKey(v *viper.Viper, key string, out interface{}) error {
+	if s, ok := v.Get(key).(string); ok {
+		var raw []map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &raw); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		// let viper decode hooks parse durations the same way as for config file
+		tmp := viper.New()
+		tmp.Set("list", raw)
+		v, key = tmp, "list"
+	}
+	if err := v.UnmarshalKey(key, out); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
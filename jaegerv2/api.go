@@ -0,0 +1,71 @@
+package jaegerv2
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Types below mirror tracestore API of Jaeger v2 (internal/storage/v2/api/tracestore), which isn't importable
+// outside of Jaeger repository. Storage extension adapts them to its version of the API field by field.
+
+// Factory creates trace readers and writers of one storage
+type Factory interface {
+	CreateTraceReader() (Reader, error)
+	CreateTraceWriter() (Writer, error)
+}
+
+// Writer writes traces in OTLP format
+type Writer interface {
+	WriteTraces(ctx context.Context, td ptrace.Traces) error
+}
+
+// Reader finds and loads traces in OTLP format. Every trace is returned as separate ptrace.Traces.
+type Reader interface {
+	GetTraces(ctx context.Context, traceIDs ...GetTraceParams) iter.Seq2[[]ptrace.Traces, error]
+	GetServices(ctx context.Context) ([]string, error)
+	GetOperations(ctx context.Context, query OperationQueryParams) ([]Operation, error)
+	FindTraces(ctx context.Context, query TraceQueryParams) iter.Seq2[[]ptrace.Traces, error]
+	FindTraceIDs(ctx context.Context, query TraceQueryParams) iter.Seq2[[]FoundTraceID, error]
+}
+
+// GetTraceParams identifies trace to load, time range is a hint and isn't used by YDB storage
+type GetTraceParams struct {
+	TraceID pcommon.TraceID
+	Start   time.Time
+	End     time.Time
+}
+
+// TraceQueryParams are search parameters, attributes are matched as indexed tags
+type TraceQueryParams struct {
+	ServiceName   string
+	OperationName string
+	Attributes    pcommon.Map
+	StartTimeMin  time.Time
+	StartTimeMax  time.Time
+	DurationMin   time.Duration
+	DurationMax   time.Duration
+	SearchDepth   int
+}
+
+// OperationQueryParams selects operations of service, optionally of one span kind
+type OperationQueryParams struct {
+	ServiceName string
+	SpanKind    string
+}
+
+// Operation is operation name of service with its span kind
+type Operation struct {
+	Name     string
+	SpanKind string
+}
+
+// FoundTraceID is trace id matching search parameters, time range is left zero
+type FoundTraceID struct {
+	TraceID pcommon.TraceID
+	Start   time.Time
+	End     time.Time
+}
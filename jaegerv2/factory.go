@@ -0,0 +1,46 @@
+package jaegerv2
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/viper"
+
+	"github.com/ydb-platform/jaeger-ydb-store/plugin"
+)
+
+var _ Factory = (*YdbFactory)(nil)
+
+// YdbFactory creates trace readers and writers on top of plugin storage, so v2 storage extension uses the same
+// configuration keys, YDB connection, writer buffers and metrics as gRPC storage plugin
+type YdbFactory struct {
+	storage *plugin.YdbStorage
+}
+
+// NewFactory connects to YDB with configuration of v, the same keys as for gRPC storage plugin are used
+func NewFactory(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logger) (*YdbFactory, error) {
+	storage, err := plugin.NewYdbStorage(ctx, v, jaegerLogger)
+	if err != nil {
+		return nil, err
+	}
+	return &YdbFactory{storage: storage}, nil
+}
+
+func (f *YdbFactory) CreateTraceReader() (Reader, error) {
+	return newTraceReader(f.storage.OtlpSpanReader()), nil
+}
+
+func (f *YdbFactory) CreateTraceWriter() (Writer, error) {
+	return newTraceWriter(f.storage.SpanWriter()), nil
+}
+
+// Storage returns plugin storage, e.g. for its dependency reader or archive storage
+func (f *YdbFactory) Storage() *plugin.YdbStorage {
+	return f.storage
+}
+
+// Close flushes writers
+func (f *YdbFactory) Close() error {
+	f.storage.Close()
+	return nil
+}
@@ -0,0 +1,115 @@
+package jaegerv2
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/reader"
+)
+
+// traceReader loads spans with span reader of plugin, which must attach OTLP fields of spans to them
+type traceReader struct {
+	spanReader spanstore.Reader
+}
+
+func newTraceReader(spanReader spanstore.Reader) *traceReader {
+	return &traceReader{spanReader: spanReader}
+}
+
+// GetTraces yields traces one by one, traces which aren't found are skipped
+func (r *traceReader) GetTraces(ctx context.Context, traceIDs ...GetTraceParams) iter.Seq2[[]ptrace.Traces, error] {
+	return func(yield func([]ptrace.Traces, error) bool) {
+		for _, params := range traceIDs {
+			trace, err := r.spanReader.GetTrace(ctx, traceIDToModel(params.TraceID))
+			if isNotFound(err) {
+				continue
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield([]ptrace.Traces{tracesFromSpans(trace.Spans)}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *traceReader) GetServices(ctx context.Context) ([]string, error) {
+	return r.spanReader.GetServices(ctx)
+}
+
+func (r *traceReader) GetOperations(ctx context.Context, query OperationQueryParams) ([]Operation, error) {
+	ops, err := r.spanReader.GetOperations(ctx, spanstore.OperationQueryParameters{
+		ServiceName: query.ServiceName,
+		SpanKind:    query.SpanKind,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Operation, 0, len(ops))
+	for _, op := range ops {
+		res = append(res, Operation{Name: op.Name, SpanKind: op.SpanKind})
+	}
+	return res, nil
+}
+
+// FindTraces yields found traces one by one
+func (r *traceReader) FindTraces(ctx context.Context, query TraceQueryParams) iter.Seq2[[]ptrace.Traces, error] {
+	return func(yield func([]ptrace.Traces, error) bool) {
+		traces, err := r.spanReader.FindTraces(ctx, traceQuery(query))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, trace := range traces {
+			if !yield([]ptrace.Traces{tracesFromSpans(trace.Spans)}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// FindTraceIDs yields all found ids at once, their time range isn't known to YDB storage
+func (r *traceReader) FindTraceIDs(ctx context.Context, query TraceQueryParams) iter.Seq2[[]FoundTraceID, error] {
+	return func(yield func([]FoundTraceID, error) bool) {
+		ids, err := r.spanReader.FindTraceIDs(ctx, traceQuery(query))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		found := make([]FoundTraceID, 0, len(ids))
+		for _, id := range ids {
+			found = append(found, FoundTraceID{TraceID: traceIDFromModel(id)})
+		}
+		yield(found, nil)
+	}
+}
+
+// traceQuery converts search parameters, attributes are matched as tags with string values
+func traceQuery(query TraceQueryParams) *spanstore.TraceQueryParameters {
+	tags := make(map[string]string, query.Attributes.Len())
+	query.Attributes.Range(func(k string, v pcommon.Value) bool {
+		tags[k] = v.AsString()
+		return true
+	})
+	return &spanstore.TraceQueryParameters{
+		ServiceName:   query.ServiceName,
+		OperationName: query.OperationName,
+		Tags:          tags,
+		StartTimeMin:  query.StartTimeMin,
+		StartTimeMax:  query.StartTimeMax,
+		DurationMin:   query.DurationMin,
+		DurationMax:   query.DurationMax,
+		NumTraces:     query.SearchDepth,
+	}
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, spanstore.ErrTraceNotFound) || errors.Is(err, reader.ErrTraceNotFound)
+}
@@ -0,0 +1,434 @@
+package jaegerv2
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/jaegertracing/jaeger/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+// Tags and log fields made of OTLP fields the same way Jaeger collector translates OTLP spans,
+// so spans written by v2 storage are searched and shown by Jaeger v1 query as usual
+const (
+	tagSpanKind          = "span.kind"
+	tagError             = "error"
+	tagScopeName         = "otel.scope.name"
+	tagScopeVersion      = "otel.scope.version"
+	tagLibraryName       = "otel.library.name" // deprecated name of otel.scope.name
+	tagLibraryVersion    = "otel.library.version"
+	tagStatusCode        = "otel.status_code"
+	tagStatusDescription = "otel.status_description"
+	tagTraceState        = "w3c.tracestate"
+	fieldEventName       = "event"
+
+	attrServiceName = "service.name"
+	noServiceName   = "OTLPResourceNoServiceName"
+)
+
+var spanKinds = map[ptrace.SpanKind]string{
+	ptrace.SpanKindClient:   "client",
+	ptrace.SpanKindServer:   "server",
+	ptrace.SpanKindProducer: "producer",
+	ptrace.SpanKindConsumer: "consumer",
+	ptrace.SpanKindInternal: "internal",
+}
+
+var statusCodes = map[ptrace.StatusCode]dbmodel.Status_StatusCode{
+	ptrace.StatusCodeUnset: dbmodel.Status_STATUS_CODE_UNSET,
+	ptrace.StatusCodeOk:    dbmodel.Status_STATUS_CODE_OK,
+	ptrace.StatusCodeError: dbmodel.Status_STATUS_CODE_ERROR,
+}
+
+var statusCodeTags = map[dbmodel.Status_StatusCode]string{
+	dbmodel.Status_STATUS_CODE_OK:    "OK",
+	dbmodel.Status_STATUS_CODE_ERROR: "ERROR",
+}
+
+// spansFromTraces translates OTLP spans to Jaeger spans, OTLP fields are attached to them with dbmodel.AttachOtlp
+func spansFromTraces(td ptrace.Traces) ([]*model.Span, error) {
+	spans := make([]*model.Span, 0, td.SpanCount())
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		service, processTags := processFromResource(rs.Resource())
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			ss := sss.At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				// every span gets its own process, writer may change it in place
+				process := model.NewProcess(service, append([]model.KeyValue(nil), processTags...))
+				span, err := spanFromOtlp(ss.Spans().At(k), ss.Scope(), process)
+				if err != nil {
+					return nil, err
+				}
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans, nil
+}
+
+func processFromResource(res pcommon.Resource) (string, []model.KeyValue) {
+	service := noServiceName
+	var tags []model.KeyValue
+	res.Attributes().Range(func(k string, v pcommon.Value) bool {
+		if k == attrServiceName {
+			service = v.AsString()
+		} else {
+			tags = append(tags, keyValue(k, v))
+		}
+		return true
+	})
+	return service, tags
+}
+
+func spanFromOtlp(s ptrace.Span, scope pcommon.InstrumentationScope, process *model.Process) (*model.Span, error) {
+	traceID := traceIDToModel(s.TraceID())
+	span := &model.Span{
+		TraceID:       traceID,
+		SpanID:        spanIDToModel(s.SpanID()),
+		OperationName: s.Name(),
+		Flags:         model.Flags(s.Flags() & 0xff),
+		StartTime:     s.StartTimestamp().AsTime(),
+		Duration:      s.EndTimestamp().AsTime().Sub(s.StartTimestamp().AsTime()),
+		Process:       process,
+	}
+	if !s.ParentSpanID().IsEmpty() {
+		span.References = append(span.References, model.NewChildOfRef(traceID, spanIDToModel(s.ParentSpanID())))
+	}
+
+	otlp := &dbmodel.OtlpSpan{TraceState: s.TraceState().AsRaw()}
+	if scope.Name() != "" || scope.Version() != "" || scope.Attributes().Len() > 0 {
+		otlp.Scope = &dbmodel.InstrumentationScope{
+			Name:       scope.Name(),
+			Version:    scope.Version(),
+			Attributes: keyValues(scope.Attributes()),
+		}
+	}
+	if s.Status().Code() != ptrace.StatusCodeUnset || s.Status().Message() != "" {
+		otlp.Status = &dbmodel.Status{Code: statusCodes[s.Status().Code()], Message: s.Status().Message()}
+	}
+	for i := 0; i < s.Links().Len(); i++ {
+		l := s.Links().At(i)
+		linkedTrace := traceIDToModel(l.TraceID())
+		span.References = append(span.References, model.NewFollowsFromRef(linkedTrace, spanIDToModel(l.SpanID())))
+		otlp.Links = append(otlp.Links, dbmodel.Link{
+			TraceIdHigh: linkedTrace.High,
+			TraceIdLow:  linkedTrace.Low,
+			SpanId:      uint64(spanIDToModel(l.SpanID())),
+			TraceState:  l.TraceState().AsRaw(),
+			Attributes:  keyValues(l.Attributes()),
+		})
+	}
+	for i := 0; i < s.Events().Len(); i++ {
+		e := s.Events().At(i)
+		fields := keyValues(e.Attributes())
+		if e.Name() != "" {
+			fields = append(fields, model.String(fieldEventName, e.Name()))
+		}
+		span.Logs = append(span.Logs, model.Log{Timestamp: e.Timestamp().AsTime(), Fields: fields})
+		otlp.EventNames = append(otlp.EventNames, e.Name())
+	}
+
+	span.Tags = append(keyValues(s.Attributes()), otlpTags(otlp)...)
+	if kind, ok := spanKinds[s.Kind()]; ok {
+		span.Tags = append(span.Tags, model.String(tagSpanKind, kind))
+	}
+	if err := dbmodel.AttachOtlp(span, otlp); err != nil {
+		return nil, err
+	}
+	return span, nil
+}
+
+// otlpTags returns tags Jaeger collector makes of OTLP fields
+func otlpTags(otlp *dbmodel.OtlpSpan) []model.KeyValue {
+	var tags []model.KeyValue
+	if name := otlp.GetScope().GetName(); name != "" {
+		tags = append(tags, model.String(tagScopeName, name))
+	}
+	if version := otlp.GetScope().GetVersion(); version != "" {
+		tags = append(tags, model.String(tagScopeVersion, version))
+	}
+	if code, ok := statusCodeTags[otlp.GetStatus().GetCode()]; ok {
+		tags = append(tags, model.String(tagStatusCode, code))
+	}
+	if otlp.GetStatus().GetCode() == dbmodel.Status_STATUS_CODE_ERROR {
+		tags = append(tags, model.Bool(tagError, true))
+	}
+	if msg := otlp.GetStatus().GetMessage(); msg != "" {
+		tags = append(tags, model.String(tagStatusDescription, msg))
+	}
+	if otlp.GetTraceState() != "" {
+		tags = append(tags, model.String(tagTraceState, otlp.GetTraceState()))
+	}
+	return tags
+}
+
+// tracesFromSpans translates spans of one trace to OTLP. Spans written by v2 storage are restored from OTLP fields
+// stored with them, spans written by Jaeger collector get OTLP fields from tags and logs it made of them.
+func tracesFromSpans(spans []*model.Span) ptrace.Traces {
+	td := ptrace.NewTraces()
+	resources := make(map[string]ptrace.ResourceSpans)
+	scopes := make(map[string]ptrace.ScopeSpans)
+	for _, span := range spans {
+		otlp := dbmodel.DetachOtlp(span)
+		if otlp != nil {
+			stripEventNames(span, otlp)
+		} else {
+			otlp = otlpFromTags(span)
+		}
+		kind := spanKind(span)
+		stripOtlpTags(span, otlp)
+
+		resKey := processKey(span.Process)
+		rs, ok := resources[resKey]
+		if !ok {
+			rs = td.ResourceSpans().AppendEmpty()
+			putProcess(rs.Resource(), span.Process)
+			resources[resKey] = rs
+		}
+		sKey := resKey + "\x00" + scopeKey(otlp.GetScope())
+		ss, ok := scopes[sKey]
+		if !ok {
+			ss = rs.ScopeSpans().AppendEmpty()
+			ss.Scope().SetName(otlp.GetScope().GetName())
+			ss.Scope().SetVersion(otlp.GetScope().GetVersion())
+			putAttributes(ss.Scope().Attributes(), otlp.GetScope().GetAttributes())
+			scopes[sKey] = ss
+		}
+		spanToOtlp(span, otlp, kind, ss.Spans().AppendEmpty())
+	}
+	return td
+}
+
+func spanToOtlp(span *model.Span, otlp *dbmodel.OtlpSpan, kind ptrace.SpanKind, s ptrace.Span) {
+	s.SetTraceID(traceIDFromModel(span.TraceID))
+	s.SetSpanID(spanIDFromModel(span.SpanID))
+	s.SetName(span.OperationName)
+	s.SetFlags(uint32(span.Flags))
+	s.SetKind(kind)
+	s.SetStartTimestamp(pcommon.NewTimestampFromTime(span.StartTime))
+	s.SetEndTimestamp(pcommon.NewTimestampFromTime(span.StartTime.Add(span.Duration)))
+	s.TraceState().FromRaw(otlp.GetTraceState())
+	putAttributes(s.Attributes(), span.Tags)
+	if st := otlp.GetStatus(); st != nil {
+		for code, c := range statusCodes {
+			if c == st.GetCode() {
+				s.Status().SetCode(code)
+			}
+		}
+		s.Status().SetMessage(st.GetMessage())
+	}
+
+	links := otlp.GetLinks()
+	for _, ref := range span.References {
+		if ref.RefType == model.ChildOf && ref.TraceID == span.TraceID && s.ParentSpanID().IsEmpty() {
+			s.SetParentSpanID(spanIDFromModel(ref.SpanID))
+			continue
+		}
+		l := s.Links().AppendEmpty()
+		l.SetTraceID(traceIDFromModel(ref.TraceID))
+		l.SetSpanID(spanIDFromModel(ref.SpanID))
+		// stored links follow references they were translated to
+		if len(links) > 0 && links[0].TraceIdHigh == ref.TraceID.High && links[0].TraceIdLow == ref.TraceID.Low &&
+			links[0].SpanId == uint64(ref.SpanID) {
+			l.TraceState().FromRaw(links[0].TraceState)
+			putAttributes(l.Attributes(), links[0].Attributes)
+			links = links[1:]
+		}
+	}
+
+	for i, log := range span.Logs {
+		e := s.Events().AppendEmpty()
+		e.SetTimestamp(pcommon.NewTimestampFromTime(log.Timestamp))
+		if i < len(otlp.GetEventNames()) {
+			e.SetName(otlp.GetEventNames()[i])
+		}
+		putAttributes(e.Attributes(), log.Fields)
+	}
+}
+
+// otlpFromTags collects OTLP fields from tags and logs Jaeger collector made of them,
+// event names are taken out of log fields as they aren't stored anywhere else
+func otlpFromTags(span *model.Span) *dbmodel.OtlpSpan {
+	tags := model.KeyValues(span.Tags)
+	otlp := &dbmodel.OtlpSpan{TraceState: findString(tags, tagTraceState)}
+	name, version := findString(tags, tagScopeName, tagLibraryName), findString(tags, tagScopeVersion, tagLibraryVersion)
+	if name != "" || version != "" {
+		otlp.Scope = &dbmodel.InstrumentationScope{Name: name, Version: version}
+	}
+	status := &dbmodel.Status{Message: findString(tags, tagStatusDescription)}
+	switch strings.ToUpper(findString(tags, tagStatusCode)) {
+	case "OK":
+		status.Code = dbmodel.Status_STATUS_CODE_OK
+	case "ERROR":
+		status.Code = dbmodel.Status_STATUS_CODE_ERROR
+	default:
+		if kv, ok := tags.FindByKey(tagError); ok && kv.VType == model.BoolType && kv.Bool() {
+			status.Code = dbmodel.Status_STATUS_CODE_ERROR
+		}
+	}
+	if status.Code != dbmodel.Status_STATUS_CODE_UNSET || status.Message != "" {
+		otlp.Status = status
+	}
+	for i := range span.Logs {
+		fields := span.Logs[i].Fields
+		var name string
+		for j, kv := range fields {
+			if kv.Key == fieldEventName && kv.VType == model.StringType {
+				name = kv.VStr
+				span.Logs[i].Fields = append(fields[:j:j], fields[j+1:]...)
+				break
+			}
+		}
+		otlp.EventNames = append(otlp.EventNames, name)
+	}
+	return otlp
+}
+
+// stripOtlpTags removes tags made of OTLP fields and span kind, they are restored as OTLP fields
+func stripOtlpTags(span *model.Span, otlp *dbmodel.OtlpSpan) {
+	remove := map[string]bool{tagSpanKind: true, tagTraceState: true}
+	if otlp.GetScope() != nil {
+		for _, key := range []string{tagScopeName, tagScopeVersion, tagLibraryName, tagLibraryVersion} {
+			remove[key] = true
+		}
+	}
+	if otlp.GetStatus() != nil {
+		remove[tagStatusCode] = true
+		remove[tagStatusDescription] = true
+		remove[tagError] = otlp.GetStatus().GetCode() == dbmodel.Status_STATUS_CODE_ERROR
+	}
+	tags := make([]model.KeyValue, 0, len(span.Tags))
+	for _, kv := range span.Tags {
+		if !remove[kv.Key] {
+			tags = append(tags, kv)
+		}
+	}
+	span.Tags = tags
+}
+
+// stripEventNames removes event names added to log fields by spanFromOtlp, they are the last fields of logs
+func stripEventNames(span *model.Span, otlp *dbmodel.OtlpSpan) {
+	for i, name := range otlp.GetEventNames() {
+		if i >= len(span.Logs) || name == "" {
+			continue
+		}
+		fields := span.Logs[i].Fields
+		if n := len(fields); n > 0 && fields[n-1].Key == fieldEventName && fields[n-1].AsString() == name {
+			span.Logs[i].Fields = fields[: n-1 : n-1]
+		}
+	}
+}
+
+func spanKind(span *model.Span) ptrace.SpanKind {
+	kv, ok := model.KeyValues(span.Tags).FindByKey(tagSpanKind)
+	if !ok {
+		return ptrace.SpanKindUnspecified
+	}
+	for kind, name := range spanKinds {
+		if name == kv.AsString() {
+			return kind
+		}
+	}
+	return ptrace.SpanKindUnspecified
+}
+
+func findString(tags model.KeyValues, keys ...string) string {
+	for _, key := range keys {
+		if kv, ok := tags.FindByKey(key); ok {
+			return kv.AsString()
+		}
+	}
+	return ""
+}
+
+func processKey(p *model.Process) string {
+	data, _ := p.Marshal()
+	return string(data)
+}
+
+func scopeKey(scope *dbmodel.InstrumentationScope) string {
+	if scope == nil {
+		return ""
+	}
+	data, _ := scope.Marshal()
+	return string(data)
+}
+
+func putProcess(res pcommon.Resource, p *model.Process) {
+	res.Attributes().PutStr(attrServiceName, p.GetServiceName())
+	putAttributes(res.Attributes(), p.GetTags())
+}
+
+func keyValues(attrs pcommon.Map) []model.KeyValue {
+	if attrs.Len() == 0 {
+		return nil
+	}
+	kvs := make([]model.KeyValue, 0, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		kvs = append(kvs, keyValue(k, v))
+		return true
+	})
+	return kvs
+}
+
+// keyValue converts attribute to tag, maps and slices are kept as JSON strings
+func keyValue(k string, v pcommon.Value) model.KeyValue {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		return model.String(k, v.Str())
+	case pcommon.ValueTypeBool:
+		return model.Bool(k, v.Bool())
+	case pcommon.ValueTypeInt:
+		return model.Int64(k, v.Int())
+	case pcommon.ValueTypeDouble:
+		return model.Float64(k, v.Double())
+	case pcommon.ValueTypeBytes:
+		return model.Binary(k, v.Bytes().AsRaw())
+	default:
+		return model.String(k, v.AsString())
+	}
+}
+
+func putAttributes(attrs pcommon.Map, kvs []model.KeyValue) {
+	for _, kv := range kvs {
+		switch kv.VType {
+		case model.BoolType:
+			attrs.PutBool(kv.Key, kv.Bool())
+		case model.Int64Type:
+			attrs.PutInt(kv.Key, kv.Int64())
+		case model.Float64Type:
+			attrs.PutDouble(kv.Key, kv.Float64())
+		case model.BinaryType:
+			attrs.PutEmptyBytes(kv.Key).FromRaw(kv.Binary())
+		default:
+			attrs.PutStr(kv.Key, kv.AsString())
+		}
+	}
+}
+
+func traceIDToModel(id pcommon.TraceID) model.TraceID {
+	return model.NewTraceID(binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:]))
+}
+
+func traceIDFromModel(id model.TraceID) pcommon.TraceID {
+	var res pcommon.TraceID
+	binary.BigEndian.PutUint64(res[:8], id.High)
+	binary.BigEndian.PutUint64(res[8:], id.Low)
+	return res
+}
+
+func spanIDToModel(id pcommon.SpanID) model.SpanID {
+	return model.SpanID(binary.BigEndian.Uint64(id[:]))
+}
+
+func spanIDFromModel(id model.SpanID) pcommon.SpanID {
+	var res pcommon.SpanID
+	binary.BigEndian.PutUint64(res[:], uint64(id))
+	return res
+}
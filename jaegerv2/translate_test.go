@@ -0,0 +1,205 @@
+package jaegerv2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+// memoryStore keeps spans as they are stored in db, reading attaches OTLP fields like reader with Otlp option
+type memoryStore struct {
+	spanstore.Reader
+	spans []*dbmodel.Span
+}
+
+func (m *memoryStore) WriteSpan(_ context.Context, span *model.Span) error {
+	dbSpan, err := dbmodel.FromDomain(span)
+	if err != nil {
+		return err
+	}
+	m.spans = append(m.spans, dbSpan)
+	return nil
+}
+
+func (m *memoryStore) GetTrace(_ context.Context, traceID model.TraceID) (*model.Trace, error) {
+	trace := &model.Trace{}
+	for _, dbSpan := range m.spans {
+		span, otlp, err := dbmodel.ToDomainOtlp(dbSpan)
+		if err != nil {
+			return nil, err
+		}
+		if span.TraceID != traceID {
+			continue
+		}
+		if otlp != nil {
+			if err = dbmodel.AttachOtlp(span, otlp); err != nil {
+				return nil, err
+			}
+		}
+		trace.Spans = append(trace.Spans, span)
+	}
+	if len(trace.Spans) == 0 {
+		return nil, spanstore.ErrTraceNotFound
+	}
+	return trace, nil
+}
+
+func (m *memoryStore) FindTraceIDs(_ context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	var ids []model.TraceID
+	for _, dbSpan := range m.spans {
+		span, err := dbmodel.ToDomain(dbSpan)
+		if err != nil {
+			return nil, err
+		}
+		if span.Process.ServiceName == query.ServiceName {
+			ids = append(ids, span.TraceID)
+		}
+	}
+	return ids, nil
+}
+
+func testTraces() ptrace.Traces {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr(attrServiceName, "checkout")
+	rs.Resource().Attributes().PutStr("host.name", "node-1")
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("io.opentelemetry.http")
+	ss.Scope().SetVersion("1.2.0")
+	ss.Scope().Attributes().PutStr("scope.attr", "v")
+
+	s := ss.Spans().AppendEmpty()
+	s.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	s.SetSpanID(pcommon.SpanID{1, 1, 1, 1, 1, 1, 1, 1})
+	s.SetParentSpanID(pcommon.SpanID{2, 2, 2, 2, 2, 2, 2, 2})
+	s.SetName("POST /pay")
+	s.SetFlags(1)
+	s.SetKind(ptrace.SpanKindServer)
+	s.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	s.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+	s.TraceState().FromRaw("vendor=value")
+	s.Status().SetCode(ptrace.StatusCodeError)
+	s.Status().SetMessage("timeout")
+	s.Attributes().PutStr("http.method", "POST")
+	s.Attributes().PutInt("http.status_code", 504)
+	s.Attributes().PutDouble("ratio", 0.5)
+	s.Attributes().PutBool("retry", true)
+	s.Attributes().PutEmptyBytes("payload").FromRaw([]byte{1, 2})
+
+	e := s.Events().AppendEmpty()
+	e.SetName("exception")
+	e.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Millisecond)))
+	e.Attributes().PutStr("exception.message", "deadline exceeded")
+	e = s.Events().AppendEmpty()
+	e.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(2 * time.Millisecond)))
+	e.Attributes().PutStr("message", "no name")
+
+	l := s.Links().AppendEmpty()
+	l.SetTraceID(pcommon.TraceID{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	l.SetSpanID(pcommon.SpanID{3, 3, 3, 3, 3, 3, 3, 3})
+	l.TraceState().FromRaw("vendor=link")
+	l.Attributes().PutStr("link.kind", "batch")
+	return td
+}
+
+func TestTraceWriterReaderRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	td := testTraces()
+	store := &memoryStore{}
+	require.NoError(t, newTraceWriter(store).WriteTraces(ctx, td))
+	require.Len(t, store.spans, 1)
+
+	// Jaeger view of span has OTLP fields as tags Jaeger collector makes of them, so v1 query shows it as usual
+	span, err := dbmodel.ToDomain(store.spans[0])
+	require.NoError(t, err)
+	assert.Equal(t, "checkout", span.Process.ServiceName)
+	tags := model.KeyValues(span.Tags)
+	for key, value := range map[string]string{
+		tagScopeName:         "io.opentelemetry.http",
+		tagStatusCode:        "ERROR",
+		tagStatusDescription: "timeout",
+		tagTraceState:        "vendor=value",
+		tagSpanKind:          "server",
+		tagError:             "true",
+	} {
+		kv, ok := tags.FindByKey(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, value, kv.AsString(), key)
+	}
+	_, ok := tags.FindByKey(dbmodel.TagOtlpSpan)
+	assert.False(t, ok)
+
+	traceID := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).TraceID()
+	var result []ptrace.Traces
+	for traces, err := range newTraceReader(store).GetTraces(ctx, GetTraceParams{TraceID: traceID}, GetTraceParams{}) {
+		require.NoError(t, err)
+		result = append(result, traces...)
+	}
+	require.Len(t, result, 1)
+	assert.Equal(t, td, result[0])
+}
+
+func TestTracesFromJaegerSpans(t *testing.T) {
+	traceID := model.NewTraceID(1, 2)
+	span := &model.Span{
+		TraceID:       traceID,
+		SpanID:        3,
+		OperationName: "GET /",
+		StartTime:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Duration:      time.Second,
+		Process:       model.NewProcess("frontend", nil),
+		References: []model.SpanRef{
+			model.NewChildOfRef(traceID, 4),
+			model.NewFollowsFromRef(model.NewTraceID(5, 6), 7),
+		},
+		Tags: []model.KeyValue{
+			model.String("http.method", "GET"),
+			model.String(tagSpanKind, "client"),
+			model.String(tagLibraryName, "legacy-lib"),
+			model.String(tagStatusCode, "error"),
+			model.Bool(tagError, true),
+		},
+		Logs: []model.Log{
+			{Fields: []model.KeyValue{model.String(fieldEventName, "retry"), model.Int64("attempt", 2)}},
+		},
+	}
+	td := tracesFromSpans([]*model.Span{span})
+	require.Equal(t, 1, td.SpanCount())
+	ss := td.ResourceSpans().At(0).ScopeSpans().At(0)
+	assert.Equal(t, "legacy-lib", ss.Scope().Name())
+
+	s := ss.Spans().At(0)
+	assert.Equal(t, ptrace.SpanKindClient, s.Kind())
+	assert.Equal(t, ptrace.StatusCodeError, s.Status().Code())
+	assert.Equal(t, spanIDFromModel(4), s.ParentSpanID())
+	assert.Equal(t, map[string]any{"http.method": "GET"}, s.Attributes().AsRaw())
+	require.Equal(t, 1, s.Links().Len())
+	assert.Equal(t, traceIDFromModel(model.NewTraceID(5, 6)), s.Links().At(0).TraceID())
+	require.Equal(t, 1, s.Events().Len())
+	assert.Equal(t, "retry", s.Events().At(0).Name())
+	assert.Equal(t, map[string]any{"attempt": int64(2)}, s.Events().At(0).Attributes().AsRaw())
+}
+
+func TestTraceReader_FindTraceIDs(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	require.NoError(t, newTraceWriter(store).WriteTraces(ctx, testTraces()))
+
+	query := TraceQueryParams{ServiceName: "checkout", Attributes: pcommon.NewMap()}
+	var found []FoundTraceID
+	for ids, err := range newTraceReader(store).FindTraceIDs(ctx, query) {
+		require.NoError(t, err)
+		found = append(found, ids...)
+	}
+	assert.Equal(t, []FoundTraceID{{TraceID: pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}}}, found)
+}
@@ -0,0 +1,33 @@
+package jaegerv2
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// traceWriter writes OTLP spans with span writer of plugin, so they go through the same buffers, limits and indexes
+type traceWriter struct {
+	spanWriter spanstore.Writer
+}
+
+func newTraceWriter(spanWriter spanstore.Writer) *traceWriter {
+	return &traceWriter{spanWriter: spanWriter}
+}
+
+// WriteTraces writes every span even if some of them fail, errors of all spans are returned
+func (w *traceWriter) WriteTraces(ctx context.Context, td ptrace.Traces) error {
+	spans, err := spansFromTraces(td)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, span := range spans {
+		if err = w.spanWriter.WriteSpan(ctx, span); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
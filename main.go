@@ -5,7 +5,10 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	jaegerGrpc "github.com/jaegertracing/jaeger/plugin/storage/grpc"
@@ -14,12 +17,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
 
+	"github.com/ydb-platform/jaeger-ydb-store/internal/remote"
 	localViper "github.com/ydb-platform/jaeger-ydb-store/internal/viper"
 	"github.com/ydb-platform/jaeger-ydb-store/plugin"
 )
 
 func init() {
 	viper.SetDefault("plugin_http_listen_address", ":15000")
+	viper.SetDefault("plugin_grpc_shutdown_timeout", time.Second*30)
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	viper.AutomaticEnv()
 }
@@ -49,14 +54,54 @@ func main() {
 
 	go serveHttp(ydbPlugin.Registry(), jaegerLogger)
 
+	services := &shared.PluginServices{
+		Store:               ydbPlugin,
+		ArchiveStore:        ydbPlugin,
+		StreamingSpanWriter: ydbPlugin,
+	}
+	if viper.GetString("plugin_grpc_listen_address") != "" {
+		if err = serveRemote(ctx, services, jaegerLogger); err != nil {
+			jaegerLogger.Error(err.Error())
+			ydbPlugin.Close()
+			os.Exit(1)
+		}
+		jaegerLogger.Warn("stopped")
+		return
+	}
+
 	jaegerLogger.Warn("starting plugin")
-	jaegerGrpc.Serve(&shared.PluginServices{
-		Store:        ydbPlugin,
-		ArchiveStore: ydbPlugin,
-	})
+	jaegerGrpc.Serve(services)
 	jaegerLogger.Warn("stopped")
 }
 
+// serveRemote runs standalone remote storage server until SIGINT or SIGTERM
+func serveRemote(ctx context.Context, services *shared.PluginServices, jaegerLogger hclog.Logger) error {
+	server, err := remote.NewServer(remote.Options{
+		Address:         viper.GetString("plugin_grpc_listen_address"),
+		TLSCertFile:     viper.GetString("plugin_grpc_tls_cert_file"),
+		TLSKeyFile:      viper.GetString("plugin_grpc_tls_key_file"),
+		TLSClientCAFile: viper.GetString("plugin_grpc_tls_client_ca_file"),
+		ShutdownTimeout: viper.GetDuration("plugin_grpc_shutdown_timeout"),
+	}, services, jaegerLogger)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	jaegerLogger.Warn("shutting down remote storage")
+	server.Shutdown()
+	return <-errCh
+}
+
 func serveHttp(gatherer prometheus.Gatherer, jaegerLogger hclog.Logger) {
 	mux := http.NewServeMux()
 	jaegerLogger.Warn("serving metrics", "addr", viper.GetString("plugin_http_listen_address"))
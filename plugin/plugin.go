@@ -3,6 +3,7 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -18,10 +19,17 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	localViper "github.com/ydb-platform/jaeger-ydb-store/internal/viper"
 	"github.com/ydb-platform/jaeger-ydb-store/schema"
 	"github.com/ydb-platform/jaeger-ydb-store/storage/config"
 	ydbDepStore "github.com/ydb-platform/jaeger-ydb-store/storage/dependencystore"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/indexer"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/ratelimit"
 	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/reader"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/redaction"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/sampling"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/spool"
 	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer"
 )
 
@@ -31,12 +39,19 @@ type YdbStorage struct {
 	logger          *zap.Logger
 	jaegerLogger    hclog.Logger
 	ydbPool         table.Client
+	secondaryPool   table.Client
 	opts            config.Options
 
-	writer        *writer.SpanWriter
-	reader        *reader.SpanReader
-	archiveWriter *writer.SpanWriter
-	archiveReader *reader.SpanReader
+	redactor        *redaction.Redactor
+	tagPolicy       *indexer.TagPolicySource
+	writer          *writer.SpanWriter
+	secondaryWriter *writer.SpanWriter
+	reader          *reader.SpanReader
+	otlpReader      *reader.SpanReader
+	streamWriter    *streamWriter
+	archiveWriter   *writer.SpanWriter
+	archiveReader   *reader.SpanReader
+	depReader       *ydbDepStore.DependencyStore
 }
 
 func NewYdbStorage(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logger) (*YdbStorage, error) {
@@ -44,10 +59,14 @@ func NewYdbStorage(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logge
 	v.SetDefault(db.KeyYdbWriterBufferSize, 1000)
 	v.SetDefault(db.KeyYdbWriterBatchSize, 100)
 	v.SetDefault(db.KeyYdbWriterBatchWorkers, 10)
+	v.SetDefault(db.KeyYdbWriterBatchBytes, "16mb")
+	v.SetDefault(db.KeyYdbWriterFlushInterval, time.Second)
 	v.SetDefault(db.KeyYdbWriterSvcOpCacheSize, 256)
+	v.SetDefault(db.KeyYdbWriterNamesFlushInterval, time.Second)
 	v.SetDefault(db.KeyYdbIndexerBufferSize, 1000)
 	v.SetDefault(db.KeyYdbIndexerMaxTraces, 100)
 	v.SetDefault(db.KeyYdbIndexerMaxTTL, time.Second*5)
+	v.SetDefault(db.KeyYdbIndexerTagPolicyReloadInterval, time.Second*30)
 	v.SetDefault(db.KeyYdbPoolSize, 100)
 	v.SetDefault(db.KeyYdbQueryCacheSize, 50)
 	v.SetDefault(db.KeyYdbReadTimeout, time.Second*10)
@@ -56,6 +75,31 @@ func NewYdbStorage(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logge
 	v.SetDefault(db.KeyYdbReadSvcLimit, 1000)
 	// Zero stands for "unbound" interval so any span age is good.
 	v.SetDefault(db.KeyYdbWriterMaxSpanAge, time.Duration(0))
+	v.SetDefault(db.KeyYdbWriterFutureSkewPolicy, string(writer.FutureSkewReject))
+	v.SetDefault(db.KeyYdbWriterPartitionsMaxAge, time.Hour*24)
+	v.SetDefault(db.KeyYdbWriterPartitionsMaxAhead, time.Hour*24)
+	v.SetDefault(db.KeyYdbWriterDependenciesWindow, time.Minute)
+	v.SetDefault(db.KeyYdbWriterDependenciesMaxSpans, 100000)
+	v.SetDefault(db.KeyYdbWriterDependenciesMaxLinks, 10000)
+	v.SetDefault(db.KeyYdbWriterShutdownTimeout, time.Second*10)
+	v.SetDefault(db.KeyYdbWriterStreamingEnabled, true)
+	v.SetDefault(db.KeyYdbWriterDedupMaxSpans, 100000)
+	v.SetDefault(db.KeyYdbWriterSamplingDecisionWait, time.Second*10)
+	v.SetDefault(db.KeyYdbWriterSamplingMaxTraces, 50000)
+	v.SetDefault(db.KeyYdbWriterSamplingDecisionCacheSize, 100000)
+	v.SetDefault(db.KeyYdbWriterRateLimitBurst, time.Second)
+	v.SetDefault(db.KeyYdbWriterOverflowPolicy, string(batch.OverflowDrop))
+	v.SetDefault(db.KeyYdbWriterOverflowTimeout, time.Second)
+	v.SetDefault(db.KeyYdbWriterArchiveOverflowPolicy, string(batch.OverflowDrop))
+	v.SetDefault(db.KeyYdbWriterArchiveOverflowTimeout, time.Second)
+	v.SetDefault(db.KeyYdbWriterRetryMaxAttempts, 5)
+	v.SetDefault(db.KeyYdbWriterRetryInitialInterval, time.Second)
+	v.SetDefault(db.KeyYdbWriterRetryMaxInterval, 30*time.Second)
+	v.SetDefault(db.KeyYdbWriterRetryQueueSize, 100)
+	v.SetDefault(db.KeyYdbWriterSpoolMaxSize, "1gb")
+	v.SetDefault(db.KeyYdbWriterSpoolSegmentSize, "64mb")
+	v.SetDefault(db.KeyYdbWriterSpoolSync, string(spool.SyncInterval))
+	v.SetDefault(db.KeyYdbWriterSpoolSyncInterval, time.Second)
 
 	registry := prometheus.NewRegistry()
 
@@ -70,13 +114,17 @@ func NewYdbStorage(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logge
 			Path:   v.GetString(db.KeyYdbPath),
 			Folder: v.GetString(db.KeyYdbFolder),
 		},
+		SecondaryDbAddress:  v.GetString(db.KeyYdbSecondaryAddress),
 		PoolSize:            v.GetInt(db.KeyYdbPoolSize),
 		QueryCacheSize:      v.GetInt(db.KeyYdbQueryCacheSize),
 		ConnectTimeout:      v.GetDuration(db.KeyYdbConnectTimeout),
 		BufferSize:          v.GetInt(db.KeyYdbWriterBufferSize),
 		BatchSize:           v.GetInt(db.KeyYdbWriterBatchSize),
 		BatchWorkers:        v.GetInt(db.KeyYdbWriterBatchWorkers),
+		BatchBytes:          int(v.GetSizeInBytes(db.KeyYdbWriterBatchBytes)),
+		FlushInterval:       v.GetDuration(db.KeyYdbWriterFlushInterval),
 		WriteSvcOpCacheSize: v.GetInt(db.KeyYdbWriterSvcOpCacheSize),
+		WriteNamesInterval:  v.GetDuration(db.KeyYdbWriterNamesFlushInterval),
 		IndexerBufferSize:   v.GetInt(db.KeyYdbIndexerBufferSize),
 		IndexerMaxTraces:    v.GetInt(db.KeyYdbIndexerMaxTraces),
 		IndexerMaxTTL:       v.GetDuration(db.KeyYdbIndexerMaxTTL),
@@ -87,6 +135,94 @@ func NewYdbStorage(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logge
 		ReadOpLimit:         v.GetUint64(db.KeyYdbReadOpLimit),
 		ReadSvcLimit:        v.GetUint64(db.KeyYdbReadSvcLimit),
 		WriteMaxSpanAge:     v.GetDuration(db.KeyYdbWriterMaxSpanAge),
+		WriteMaxFutureSkew:  v.GetDuration(db.KeyYdbWriterMaxFutureSkew),
+		WriteStreaming:      v.GetBool(db.KeyYdbWriterStreamingEnabled),
+
+		DependenciesEnabled:  v.GetBool(db.KeyYdbWriterDependenciesEnabled),
+		DependenciesWindow:   v.GetDuration(db.KeyYdbWriterDependenciesWindow),
+		DependenciesMaxSpans: v.GetInt(db.KeyYdbWriterDependenciesMaxSpans),
+		DependenciesMaxLinks: v.GetInt(db.KeyYdbWriterDependenciesMaxLinks),
+
+		WriterShutdownTimeout: v.GetDuration(db.KeyYdbWriterShutdownTimeout),
+
+		CreatePartitions:         v.GetBool(db.KeyYdbWriterPartitionsCreate),
+		CreatePartitionsMaxAge:   v.GetDuration(db.KeyYdbWriterPartitionsMaxAge),
+		CreatePartitionsMaxAhead: v.GetDuration(db.KeyYdbWriterPartitionsMaxAhead),
+		PartitionCounts:          schema.PartitionCounts(v),
+
+		DedupWindow:   v.GetDuration(db.KeyYdbWriterDedupWindow),
+		DedupMaxSpans: v.GetInt(db.KeyYdbWriterDedupMaxSpans),
+		DedupSpans:    v.GetBool(db.KeyYdbWriterDedupSpans),
+
+		TagPolicyFile:           v.GetString(db.KeyYdbIndexerTagPolicyFile),
+		TagPolicyReloadInterval: v.GetDuration(db.KeyYdbIndexerTagPolicyReloadInterval),
+
+		SamplingEnabled: v.GetBool(db.KeyYdbWriterSamplingEnabled),
+		Sampling: sampling.Options{
+			DecisionWait:      v.GetDuration(db.KeyYdbWriterSamplingDecisionWait),
+			MaxTraces:         v.GetInt(db.KeyYdbWriterSamplingMaxTraces),
+			DecisionCacheSize: v.GetInt(db.KeyYdbWriterSamplingDecisionCacheSize),
+		},
+
+		Redaction: redaction.Options{
+			HashSalt: v.GetString(db.KeyYdbWriterRedactionHashSalt),
+		},
+
+		RateLimitEnabled: v.GetBool(db.KeyYdbWriterRateLimitEnabled),
+		RateLimit: ratelimit.Options{
+			Default: ratelimit.LimitConfig{
+				SpansPerSecond: v.GetFloat64(db.KeyYdbWriterRateLimitSpansPerSecond),
+				BytesPerSecond: v.GetFloat64(db.KeyYdbWriterRateLimitBytesPerSecond),
+			},
+			Burst: v.GetDuration(db.KeyYdbWriterRateLimitBurst),
+		},
+
+		SpanMaxTagValueLength: v.GetInt(db.KeyYdbWriterLimitsMaxTagValueLength),
+		SpanMaxTags:           v.GetInt(db.KeyYdbWriterLimitsMaxTags),
+		SpanMaxLogs:           v.GetInt(db.KeyYdbWriterLimitsMaxLogs),
+		SpanMaxBytes:          int(v.GetSizeInBytes(db.KeyYdbWriterLimitsMaxSpanSize)),
+
+		WriterOverflowTimeout:        v.GetDuration(db.KeyYdbWriterOverflowTimeout),
+		ArchiveWriterOverflowTimeout: v.GetDuration(db.KeyYdbWriterArchiveOverflowTimeout),
+
+		RetryMaxAttempts:     v.GetInt(db.KeyYdbWriterRetryMaxAttempts),
+		RetryInitialInterval: v.GetDuration(db.KeyYdbWriterRetryInitialInterval),
+		RetryMaxInterval:     v.GetDuration(db.KeyYdbWriterRetryMaxInterval),
+		RetryQueueSize:       v.GetInt(db.KeyYdbWriterRetryQueueSize),
+
+		SpoolDir:          v.GetString(db.KeyYdbWriterSpoolDir),
+		SpoolMaxSize:      int64(v.GetSizeInBytes(db.KeyYdbWriterSpoolMaxSize)),
+		SpoolSegmentSize:  int64(v.GetSizeInBytes(db.KeyYdbWriterSpoolSegmentSize)),
+		SpoolSync:         v.GetString(db.KeyYdbWriterSpoolSync),
+		SpoolSyncInterval: v.GetDuration(db.KeyYdbWriterSpoolSyncInterval),
+	}
+
+	var err error
+	if p.opts.SamplingEnabled {
+		if err = localViper.UnmarshalListKey(v, db.KeyYdbWriterSamplingPolicies, &p.opts.Sampling.Policies); err != nil {
+			return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+		}
+	}
+	if p.opts.RateLimitEnabled {
+		if err = localViper.UnmarshalListKey(v, db.KeyYdbWriterRateLimitOverrides, &p.opts.RateLimit.Overrides); err != nil {
+			return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+		}
+	}
+	if err = localViper.UnmarshalListKey(v, db.KeyYdbWriterRedactionRules, &p.opts.Redaction.Rules); err != nil {
+		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+	}
+	if p.opts.WriteFutureSkew, err = writer.ParseFutureSkewPolicy(v.GetString(db.KeyYdbWriterFutureSkewPolicy)); err != nil {
+		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+	}
+	if p.opts.WriterOverflow, err = batch.ParseOverflowPolicy(v.GetString(db.KeyYdbWriterOverflowPolicy)); err != nil {
+		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+	}
+	if p.opts.ArchiveWriterOverflow, err = batch.ParseOverflowPolicy(v.GetString(db.KeyYdbWriterArchiveOverflowPolicy)); err != nil {
+		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+	}
+	if p.opts.SamplingEnabled && p.opts.WriterOverflow != batch.OverflowDrop {
+		// sampled spans are written after WriteSpan has returned, nobody is there to retry them
+		return nil, fmt.Errorf("NewYdbStorage(): overflow policy '%s' can't be used with tail-based sampling", p.opts.WriterOverflow)
 	}
 
 	cfg := zap.NewProductionConfig()
@@ -94,7 +230,6 @@ func NewYdbStorage(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logge
 		cfg.ErrorOutputPaths = []string{logPath}
 		cfg.OutputPaths = []string{logPath}
 	}
-	var err error
 	p.logger, err = cfg.Build()
 	if err != nil {
 		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
@@ -102,16 +237,59 @@ func NewYdbStorage(ctx context.Context, v *viper.Viper, jaegerLogger hclog.Logge
 
 	p.jaegerLogger = jaegerLogger
 
-	p.ydbPool, err = p.connectToYDB(ctx, v)
+	p.ydbPool, err = p.connectToYDB(ctx, v, p.opts.DbAddress, p.opts.DbPath, p.metricsFactory)
 	if err != nil {
 		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
 	}
+	if p.opts.SecondaryDbAddress != "" {
+		sv := db.SecondaryViper(v)
+		p.opts.SecondaryDbPath = schema.DbPath{
+			Path:   sv.GetString(db.KeyYdbPath),
+			Folder: sv.GetString(db.KeyYdbFolder),
+		}
+		ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "secondary"})
+		p.secondaryPool, err = p.connectToYDB(ctx, sv, p.opts.SecondaryDbAddress, p.opts.SecondaryDbPath, ns)
+		if err != nil {
+			return nil, fmt.Errorf("NewYdbStorage(): secondary: %w", err)
+		}
+	}
+
+	if len(p.opts.Redaction.Rules) > 0 {
+		ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "redaction"})
+		if p.redactor, err = redaction.NewRedactor(p.opts.Redaction, ns); err != nil {
+			return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+		}
+	}
+
+	if p.opts.TagPolicyFile != "" {
+		ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "tag_policy"})
+		p.tagPolicy, err = indexer.WatchTagPolicyFile(p.opts.TagPolicyFile, p.opts.TagPolicyReloadInterval, ns, p.logger)
+		if err != nil {
+			return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+		}
+	}
 
-	p.writer = p.createWriter()
-	p.archiveWriter = p.createArchiveWriter()
+	if p.secondaryPool != nil {
+		if p.secondaryWriter, err = p.createSecondaryWriter(); err != nil {
+			return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+		}
+	}
+	p.writer, err = p.createWriter()
+	if err != nil {
+		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+	}
+	if p.opts.WriteStreaming {
+		p.streamWriter = newStreamWriter(p.writer, p.metricsFactory.Namespace(metrics.NSOptions{Name: "stream_writer"}))
+	}
+	p.archiveWriter, err = p.createArchiveWriter()
+	if err != nil {
+		return nil, fmt.Errorf("NewYdbStorage(): %w", err)
+	}
 
-	p.reader = p.createReader()
+	p.reader = p.createReader(false)
+	p.otlpReader = p.createReader(true)
 	p.archiveReader = p.createArchiveReader()
+	p.depReader = p.createDependencyReader()
 
 	return p, nil
 }
@@ -124,10 +302,23 @@ func (p *YdbStorage) SpanReader() spanstore.Reader {
 	return p.reader
 }
 
+// OtlpSpanReader returns reader which attaches OTLP fields stored with spans, see dbmodel.AttachOtlp
+func (p *YdbStorage) OtlpSpanReader() spanstore.Reader {
+	return p.otlpReader
+}
+
 func (p *YdbStorage) SpanWriter() spanstore.Writer {
 	return p.writer
 }
 
+// StreamingSpanWriter returns nil if streaming is disabled, so plugin doesn't report streaming capability
+func (p *YdbStorage) StreamingSpanWriter() spanstore.Writer {
+	if p.streamWriter == nil {
+		return nil
+	}
+	return p.streamWriter
+}
+
 func (p *YdbStorage) ArchiveSpanReader() spanstore.Reader {
 	return p.archiveReader
 }
@@ -136,11 +327,11 @@ func (p *YdbStorage) ArchiveSpanWriter() spanstore.Writer {
 	return p.archiveWriter
 }
 
-func (*YdbStorage) DependencyReader() dependencystore.Reader {
-	return ydbDepStore.DependencyStore{}
+func (p *YdbStorage) DependencyReader() dependencystore.Reader {
+	return p.depReader
 }
 
-func (p *YdbStorage) connectToYDB(ctx context.Context, v *viper.Viper) (table.Client, error) {
+func (p *YdbStorage) connectToYDB(ctx context.Context, v *viper.Viper, addr string, dbPath schema.DbPath, mf metrics.Factory) (table.Client, error) {
 	ctx, cancel := context.WithTimeout(ctx, p.opts.ConnectTimeout)
 	defer cancel()
 
@@ -148,10 +339,10 @@ func (p *YdbStorage) connectToYDB(ctx context.Context, v *viper.Viper) (table.Cl
 		ctx,
 		v,
 		p.logger,
-		sugar.DSN(p.opts.DbAddress, p.opts.DbPath.Path, true),
+		sugar.DSN(addr, dbPath.Path, true),
 		ydb.WithSessionPoolSizeLimit(p.opts.PoolSize),
 		ydb.WithSessionPoolKeepAliveTimeout(time.Second),
-		ydb.WithTraceTable(tableClientMetrics(p.metricsFactory)),
+		ydb.WithTraceTable(tableClientMetrics(mf)),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("YdbStorage.InitDB() %w", err)
@@ -170,11 +361,46 @@ func (p *YdbStorage) connectToYDB(ctx context.Context, v *viper.Viper) (table.Cl
 	return conn.Table(), nil
 }
 
-func (p *YdbStorage) createWriter() *writer.SpanWriter {
+func (p *YdbStorage) retryOptions() writer.RetryOptions {
+	return writer.RetryOptions{
+		MaxAttempts:     p.opts.RetryMaxAttempts,
+		InitialInterval: p.opts.RetryInitialInterval,
+		MaxInterval:     p.opts.RetryMaxInterval,
+		QueueSize:       p.opts.RetryQueueSize,
+	}
+}
+
+func (p *YdbStorage) createSpool(name string) (*spool.Spool, error) {
+	if p.opts.SpoolDir == "" {
+		return nil, nil
+	}
+	opts := spool.Options{
+		Dir:          filepath.Join(p.opts.SpoolDir, name),
+		MaxSize:      p.opts.SpoolMaxSize,
+		SegmentSize:  p.opts.SpoolSegmentSize,
+		Sync:         spool.SyncPolicy(p.opts.SpoolSync),
+		SyncInterval: p.opts.SpoolSyncInterval,
+	}
+	ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "spool", Tags: map[string]string{"writer": name}})
+	return spool.New(opts, ns, p.logger)
+}
+
+func (p *YdbStorage) spanLimits() writer.SpanLimits {
+	return writer.SpanLimits{
+		MaxTagValueLength: p.opts.SpanMaxTagValueLength,
+		MaxTags:           p.opts.SpanMaxTags,
+		MaxLogs:           p.opts.SpanMaxLogs,
+		MaxSpanBytes:      p.opts.SpanMaxBytes,
+	}
+}
+
+func (p *YdbStorage) createWriter() (*writer.SpanWriter, error) {
 	opts := writer.SpanWriterOptions{
 		BufferSize:          p.opts.BufferSize,
 		BatchSize:           p.opts.BatchSize,
 		BatchWorkers:        p.opts.BatchWorkers,
+		BatchBytes:          p.opts.BatchBytes,
+		FlushInterval:       p.opts.FlushInterval,
 		IndexerBufferSize:   p.opts.IndexerBufferSize,
 		IndexerMaxTraces:    p.opts.IndexerMaxTraces,
 		IndexerTTL:          p.opts.IndexerMaxTTL,
@@ -182,19 +408,107 @@ func (p *YdbStorage) createWriter() *writer.SpanWriter {
 		WriteTimeout:        p.opts.WriteTimeout,
 		RetryAttemptTimeout: p.opts.RetryAttemptTimeout,
 		OpCacheSize:         p.opts.WriteSvcOpCacheSize,
+		NamesFlushInterval:  p.opts.WriteNamesInterval,
 		MaxSpanAge:          p.opts.WriteMaxSpanAge,
+		MaxFutureSkew:       p.opts.WriteMaxFutureSkew,
+		FutureSkewPolicy:    p.opts.WriteFutureSkew,
+		Retry:               p.retryOptions(),
+		Redactor:            p.redactor,
+		TagPolicy:           p.tagPolicy,
+		Limits:              p.spanLimits(),
+		DedupWindow:         p.opts.DedupWindow,
+		DedupMaxSpans:       p.opts.DedupMaxSpans,
+		DedupSpans:          p.opts.DedupSpans,
+		Overflow:            p.opts.WriterOverflow,
+		OverflowTimeout:     p.opts.WriterOverflowTimeout,
+		Secondary:           p.secondaryWriter,
+	}
+	if p.opts.DependenciesEnabled {
+		opts.DependencyWindow = p.opts.DependenciesWindow
+		opts.DependencyMaxSpans = p.opts.DependenciesMaxSpans
+		opts.DependencyMaxLinks = p.opts.DependenciesMaxLinks
+	}
+	var err error
+	if opts.Spool, err = p.createSpool("primary"); err != nil {
+		return nil, err
+	}
+	if p.opts.CreatePartitions {
+		opts.Partitions = schema.NewPartitionCreator(p.ydbPool, schema.PartitionCreatorOptions{
+			DbPath:   p.opts.DbPath,
+			Counts:   p.opts.PartitionCounts,
+			MaxAge:   p.opts.CreatePartitionsMaxAge,
+			MaxAhead: p.opts.CreatePartitionsMaxAhead,
+		})
+	}
+	if p.opts.RateLimitEnabled {
+		ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "rate_limit"})
+		if opts.RateLimiter, err = ratelimit.NewLimiter(p.opts.RateLimit, ns); err != nil {
+			return nil, err
+		}
+	}
+	if p.opts.SamplingEnabled {
+		ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "sampling"})
+		if opts.Sampler, err = sampling.NewSampler(p.opts.Sampling, ns); err != nil {
+			return nil, err
+		}
 	}
 	ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "writer"})
 	w := writer.NewSpanWriter(p.ydbPool, ns, p.logger, p.jaegerLogger, opts)
-	return w
+	return w, nil
+}
+
+// createSecondaryWriter creates writer which gets spans already processed by primary writer,
+// so it has no transforms, sampling and rate limits of its own. Its buffers are dropped on overflow,
+// so slow secondary database never blocks primary one.
+func (p *YdbStorage) createSecondaryWriter() (*writer.SpanWriter, error) {
+	opts := writer.SpanWriterOptions{
+		BufferSize:          p.opts.BufferSize,
+		BatchSize:           p.opts.BatchSize,
+		BatchWorkers:        p.opts.BatchWorkers,
+		BatchBytes:          p.opts.BatchBytes,
+		FlushInterval:       p.opts.FlushInterval,
+		IndexerBufferSize:   p.opts.IndexerBufferSize,
+		IndexerMaxTraces:    p.opts.IndexerMaxTraces,
+		IndexerTTL:          p.opts.IndexerMaxTTL,
+		DbPath:              p.opts.SecondaryDbPath,
+		WriteTimeout:        p.opts.WriteTimeout,
+		RetryAttemptTimeout: p.opts.RetryAttemptTimeout,
+		OpCacheSize:         p.opts.WriteSvcOpCacheSize,
+		NamesFlushInterval:  p.opts.WriteNamesInterval,
+		Retry:               p.retryOptions(),
+		TagPolicy:           p.tagPolicy,
+		Overflow:            batch.OverflowDrop,
+	}
+	if p.opts.DependenciesEnabled {
+		opts.DependencyWindow = p.opts.DependenciesWindow
+		opts.DependencyMaxSpans = p.opts.DependenciesMaxSpans
+		opts.DependencyMaxLinks = p.opts.DependenciesMaxLinks
+	}
+	var err error
+	if opts.Spool, err = p.createSpool("secondary"); err != nil {
+		return nil, err
+	}
+	if p.opts.CreatePartitions {
+		opts.Partitions = schema.NewPartitionCreator(p.secondaryPool, schema.PartitionCreatorOptions{
+			DbPath:   p.opts.SecondaryDbPath,
+			Counts:   p.opts.PartitionCounts,
+			MaxAge:   p.opts.CreatePartitionsMaxAge,
+			MaxAhead: p.opts.CreatePartitionsMaxAhead,
+		})
+	}
+	ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "secondary_writer"})
+	w := writer.NewSpanWriter(p.secondaryPool, ns, p.logger, p.jaegerLogger, opts)
+	return w, nil
 }
 
-func (p *YdbStorage) createArchiveWriter() *writer.SpanWriter {
+func (p *YdbStorage) createArchiveWriter() (*writer.SpanWriter, error) {
 	opts := writer.SpanWriterOptions{
 		ArchiveWriter:       true,
 		BufferSize:          p.opts.BufferSize,
 		BatchSize:           p.opts.BatchSize,
 		BatchWorkers:        p.opts.BatchWorkers,
+		BatchBytes:          p.opts.BatchBytes,
+		FlushInterval:       p.opts.FlushInterval,
 		IndexerBufferSize:   p.opts.IndexerBufferSize,
 		IndexerMaxTraces:    p.opts.IndexerMaxTraces,
 		IndexerTTL:          p.opts.IndexerMaxTTL,
@@ -202,20 +516,34 @@ func (p *YdbStorage) createArchiveWriter() *writer.SpanWriter {
 		WriteTimeout:        p.opts.WriteTimeout,
 		RetryAttemptTimeout: p.opts.RetryAttemptTimeout,
 		OpCacheSize:         p.opts.WriteSvcOpCacheSize,
+		NamesFlushInterval:  p.opts.WriteNamesInterval,
 		MaxSpanAge:          p.opts.WriteMaxSpanAge,
+		MaxFutureSkew:       p.opts.WriteMaxFutureSkew,
+		FutureSkewPolicy:    p.opts.WriteFutureSkew,
+		Retry:               p.retryOptions(),
+		Redactor:            p.redactor,
+		TagPolicy:           p.tagPolicy,
+		Limits:              p.spanLimits(),
+		Overflow:            p.opts.ArchiveWriterOverflow,
+		OverflowTimeout:     p.opts.ArchiveWriterOverflowTimeout,
+	}
+	var err error
+	if opts.Spool, err = p.createSpool("archive"); err != nil {
+		return nil, err
 	}
 	ns := p.metricsFactory.Namespace(metrics.NSOptions{Name: "writer"})
 	w := writer.NewSpanWriter(p.ydbPool, ns, p.logger, p.jaegerLogger, opts)
-	return w
+	return w, nil
 }
 
-func (p *YdbStorage) createReader() *reader.SpanReader {
+func (p *YdbStorage) createReader(otlp bool) *reader.SpanReader {
 	opts := reader.SpanReaderOptions{
 		DbPath:        p.opts.DbPath,
 		ReadTimeout:   p.opts.ReadTimeout,
 		QueryParallel: p.opts.ReadQueryParallel,
 		OpLimit:       p.opts.ReadOpLimit,
 		SvcLimit:      p.opts.ReadSvcLimit,
+		Otlp:          otlp,
 	}
 	r := reader.NewSpanReader(p.ydbPool, opts, p.logger, p.jaegerLogger)
 	return r
@@ -234,7 +562,25 @@ func (p *YdbStorage) createArchiveReader() *reader.SpanReader {
 	return r
 }
 
+func (p *YdbStorage) createDependencyReader() *ydbDepStore.DependencyStore {
+	opts := ydbDepStore.Options{
+		DbPath:      p.opts.DbPath,
+		ReadTimeout: p.opts.ReadTimeout,
+	}
+	return ydbDepStore.NewDependencyStore(p.ydbPool, opts, p.logger, p.jaegerLogger)
+}
+
+// Close flushes writers, all of them share KeyYdbWriterShutdownTimeout deadline
 func (p *YdbStorage) Close() {
-	p.writer.Close()
-	p.archiveWriter.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.WriterShutdownTimeout)
+	defer cancel()
+	p.writer.Close(ctx)
+	// after primary writer, which passes spans flushed by sampler to secondary one
+	if p.secondaryWriter != nil {
+		p.secondaryWriter.Close(ctx)
+	}
+	p.archiveWriter.Close(ctx)
+	if p.tagPolicy != nil {
+		p.tagPolicy.Close()
+	}
 }
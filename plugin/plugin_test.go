@@ -0,0 +1,20 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+)
+
+func TestNewYdbStorage_SamplingOverflow(t *testing.T) {
+	v := viper.New()
+	v.Set(db.KeyYdbWriterSamplingEnabled, true)
+	v.Set(db.KeyYdbWriterOverflowPolicy, "reject")
+	_, err := NewYdbStorage(context.Background(), v, hclog.NewNullLogger())
+	assert.ErrorContains(t, err, "can't be used with tail-based sampling")
+}
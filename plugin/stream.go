@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	"github.com/uber/jaeger-lib/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// streamWriter feeds spans received from collector streams to span writer and counts stream throughput
+type streamWriter struct {
+	writer spanstore.Writer
+	spans  metrics.Counter
+	bytes  metrics.Counter
+	errors metrics.Counter
+}
+
+func newStreamWriter(w spanstore.Writer, mf metrics.Factory) *streamWriter {
+	return &streamWriter{
+		writer: w,
+		spans:  mf.Counter(metrics.Options{Name: "spans"}),
+		bytes:  mf.Counter(metrics.Options{Name: "bytes"}),
+		errors: mf.Counter(metrics.Options{Name: "errors"}),
+	}
+}
+
+// WriteSpan returns only buffer overflow errors of reject and block policies, so collector sees the backpressure.
+// Other errors would break the stream and spans sent after this one would be lost by collector,
+// they are counted and reported by span writer itself.
+func (w *streamWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	w.spans.Inc(1)
+	w.bytes.Inc(int64(span.Size()))
+	if err := w.writer.WriteSpan(ctx, span); err != nil {
+		w.errors.Inc(1)
+		if status.Code(err) == codes.ResourceExhausted {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer"
+)
+
+type failingWriter struct {
+	written int
+	err     error
+}
+
+func (w *failingWriter) WriteSpan(_ context.Context, _ *model.Span) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.written++
+	return nil
+}
+
+func TestStreamWriter(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	w := &failingWriter{}
+	sw := newStreamWriter(w, mf)
+	span := &model.Span{OperationName: "op", Process: model.NewProcess("svc", nil)}
+
+	assert.NoError(t, sw.WriteSpan(context.Background(), span))
+	assert.NoError(t, sw.WriteSpan(context.Background(), span))
+	w.err = errors.New("write error")
+	// stream isn't broken by write error
+	assert.NoError(t, sw.WriteSpan(context.Background(), span))
+	// but overflow of reject and block policies is reported to collector
+	w.err = writer.ErrBufferFull
+	assert.Equal(t, writer.ErrBufferFull, sw.WriteSpan(context.Background(), span))
+
+	assert.Equal(t, 2, w.written)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans", Value: 4},
+		metricstest.ExpectedMetric{Name: "bytes", Value: 4 * span.Size()},
+		metricstest.ExpectedMetric{Name: "errors", Value: 2},
+	)
+}
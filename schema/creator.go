@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/spf13/viper"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+)
+
+const (
+	defaultPartitionCount = 32
+	knownTablesCacheSize  = 500
+)
+
+// ErrPartitionOutOfRange is returned by CreateMissing for partitions too far from current time
+var ErrPartitionOutOfRange = errors.New("partition is out of allowed range")
+
+// PartitionCounts returns number of uniform partitions for each of PartitionTables from parts_<table> keys
+func PartitionCounts(v *viper.Viper) map[string]uint64 {
+	m := make(map[string]uint64, len(PartitionTables))
+	for name := range PartitionTables {
+		m[name] = v.GetUint64("parts_" + name)
+		if m[name] == 0 {
+			m[name] = defaultPartitionCount
+		}
+	}
+	return m
+}
+
+type PartitionCreatorOptions struct {
+	DbPath DbPath
+	// Counts are numbers of uniform partitions of PartitionTables, see PartitionCounts
+	Counts map[string]uint64
+	// MaxAge and MaxAhead limit partitions created by CreateMissing around current time, zero means no limit.
+	// MaxAge shouldn't exceed watcher expiration, otherwise watcher drops created partition right away.
+	MaxAge   time.Duration
+	MaxAhead time.Duration
+}
+
+// PartitionCreator creates partition tables and adds partition to partitions table
+type PartitionCreator struct {
+	client      table.Client
+	opts        PartitionCreatorOptions
+	knownTables *lru.Cache
+	mu          sync.Mutex
+}
+
+func NewPartitionCreator(client table.Client, opts PartitionCreatorOptions) *PartitionCreator {
+	knownTables, _ := lru.New(knownTablesCacheSize)
+	return &PartitionCreator{
+		client:      client,
+		opts:        opts,
+		knownTables: knownTables,
+	}
+}
+
+// Create creates partition tables which don't exist yet and saves partition info
+func (c *PartitionCreator) Create(ctx context.Context, part PartitionKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, def := range PartitionTables {
+		fullName := part.BuildFullTableName(c.opts.DbPath.String(), name)
+		if c.tableKnown(ctx, fullName) {
+			continue
+		}
+		err := c.client.Do(ctx, func(ctx context.Context, session table.Session) error {
+			return session.CreateTable(ctx, fullName, def(c.opts.Counts[name])...)
+		})
+		if err != nil {
+			return fmt.Errorf("create table '%s': %w", fullName, err)
+		}
+		c.knownTables.Add(fullName, struct{}{})
+	}
+	err := c.client.Do(ctx, func(ctx context.Context, session table.Session) error {
+		_, _, err := session.Execute(ctx, table.DefaultTxControl(), BuildQuery(c.opts.DbPath, InsertPart), part.QueryParams())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("save partition '%s': %w", part.Suffix(), err)
+	}
+	return nil
+}
+
+// CreateMissing creates partition which tables were reported missing by failed write,
+// ErrPartitionOutOfRange is returned for partitions outside of MaxAge and MaxAhead
+func (c *PartitionCreator) CreateMissing(ctx context.Context, part PartitionKey) error {
+	if !c.InRange(part, time.Now()) {
+		return ErrPartitionOutOfRange
+	}
+	// tables may have been dropped since they were cached
+	for name := range PartitionTables {
+		c.knownTables.Remove(part.BuildFullTableName(c.opts.DbPath.String(), name))
+	}
+	return c.Create(ctx, part)
+}
+
+// InRange checks that partition time span is within MaxAge and MaxAhead from now
+func (c *PartitionCreator) InRange(part PartitionKey, now time.Time) bool {
+	begin, end := part.TimeSpan()
+	if c.opts.MaxAge > 0 && end.Before(now.Add(-c.opts.MaxAge)) {
+		return false
+	}
+	if c.opts.MaxAhead > 0 && begin.After(now.Add(c.opts.MaxAhead)) {
+		return false
+	}
+	return true
+}
+
+func (c *PartitionCreator) tableKnown(ctx context.Context, fullName string) bool {
+	if _, ok := c.knownTables.Get(fullName); ok {
+		return true
+	}
+	err := c.client.Do(ctx, func(ctx context.Context, session table.Session) error {
+		_, err := session.DescribeTable(ctx, fullName)
+		return err
+	})
+	if err != nil {
+		return false
+	}
+	c.knownTables.Add(fullName, struct{}{})
+	return true
+}
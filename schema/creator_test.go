@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionCreator_InRange(t *testing.T) {
+	now := time.Date(2063, 4, 5, 12, 0, 0, 0, time.UTC)
+	c := NewPartitionCreator(nil, PartitionCreatorOptions{MaxAge: time.Hour * 24, MaxAhead: time.Hour * 12})
+	assert.True(t, c.InRange(PartitionFromTime(now), now))
+	assert.True(t, c.InRange(PartitionFromTime(now.Add(-time.Hour*24)), now))
+	assert.False(t, c.InRange(PartitionFromTime(now.Add(-time.Hour*48)), now))
+	assert.True(t, c.InRange(PartitionFromTime(now.Add(time.Hour*12)), now))
+	assert.False(t, c.InRange(PartitionFromTime(now.Add(time.Hour*15)), now))
+
+	unbound := NewPartitionCreator(nil, PartitionCreatorOptions{})
+	assert.True(t, unbound.InRange(PartitionFromTime(now.Add(-time.Hour*1000)), now))
+}
+
+func TestPartitionCounts(t *testing.T) {
+	v := viper.New()
+	v.Set("parts_traces", 64)
+	counts := PartitionCounts(v)
+	assert.Len(t, counts, len(PartitionTables))
+	assert.Equal(t, uint64(64), counts["traces"])
+	assert.Equal(t, uint64(defaultPartitionCount), counts["idx_duration"])
+}
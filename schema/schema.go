@@ -21,6 +21,14 @@ var (
 		"service_names":      ServiceNames,
 		"operation_names_v2": OperationNamesV2,
 		"archive":            ArchiveTraces,
+		"dependencies":       Dependencies,
+
+		"archive_service_names":      ServiceNames,
+		"archive_operation_names_v2": OperationNamesV2,
+		"archive_idx_service_name":   ArchiveServiceNameIndex,
+		"archive_idx_service_op":     ArchiveServiceOperationIndex,
+		"archive_idx_duration":       ArchiveDurationIndex,
+		"archive_idx_tag_v2":         ArchiveTagIndexV2,
 	}
 
 	// PartitionTables tables split by partition
@@ -35,13 +43,7 @@ var (
 
 // Traces returns traces table schema
 func Traces(numPartitions uint64) []options.CreateTableOption {
-	return append(
-		ArchiveTraces(),
-		options.WithPartitions(
-			options.WithUniformPartitions(numPartitions),
-		),
-		options.WithPartitioningSettingsObject(partitioningSettings(numPartitions)),
-	)
+	return append(ArchiveTraces(), partitions(numPartitions)...)
 }
 
 // ArchiveTraces returns archive_traces table schema
@@ -72,36 +74,43 @@ func ArchiveTraces() []options.CreateTableOption {
 
 // ServiceOperationIndex returns service_operation_index table schema
 func ServiceOperationIndex(numPartitions uint64) []options.CreateTableOption {
+	return append(ArchiveServiceOperationIndex(), partitions(numPartitions)...)
+}
+
+// ArchiveServiceOperationIndex returns archive_idx_service_op table schema
+func ArchiveServiceOperationIndex() []options.CreateTableOption {
 	return []options.CreateTableOption{
 		options.WithColumn("idx_hash", types.Optional(types.TypeUint64)),
 		options.WithColumn("rev_start_time", types.Optional(types.TypeInt64)),
 		options.WithColumn("uniq", types.Optional(types.TypeUint32)),
 		options.WithColumn("trace_ids", types.Optional(types.TypeString)),
 		options.WithPrimaryKeyColumn("idx_hash", "rev_start_time", "uniq"),
-		options.WithPartitions(
-			options.WithUniformPartitions(numPartitions),
-		),
-		options.WithPartitioningSettingsObject(partitioningSettings(numPartitions)),
 	}
 }
 
 // ServiceNameIndex returns service_name_index table schema
 func ServiceNameIndex(numPartitions uint64) []options.CreateTableOption {
+	return append(ArchiveServiceNameIndex(), partitions(numPartitions)...)
+}
+
+// ArchiveServiceNameIndex returns archive_idx_service_name table schema
+func ArchiveServiceNameIndex() []options.CreateTableOption {
 	return []options.CreateTableOption{
 		options.WithColumn("idx_hash", types.Optional(types.TypeUint64)),
 		options.WithColumn("rev_start_time", types.Optional(types.TypeInt64)),
 		options.WithColumn("uniq", types.Optional(types.TypeUint32)),
 		options.WithColumn("trace_ids", types.Optional(types.TypeString)),
 		options.WithPrimaryKeyColumn("idx_hash", "rev_start_time", "uniq"),
-		options.WithPartitions(
-			options.WithUniformPartitions(numPartitions),
-		),
-		options.WithPartitioningSettingsObject(partitioningSettings(numPartitions)),
 	}
 }
 
 // DurationIndex returns duration_index table schema
 func DurationIndex(numPartitions uint64) []options.CreateTableOption {
+	return append(ArchiveDurationIndex(), partitions(numPartitions)...)
+}
+
+// ArchiveDurationIndex returns archive_idx_duration table schema
+func ArchiveDurationIndex() []options.CreateTableOption {
 	return []options.CreateTableOption{
 		options.WithColumn("idx_hash", types.Optional(types.TypeUint64)),
 		options.WithColumn("duration", types.Optional(types.TypeInt64)),
@@ -109,15 +118,16 @@ func DurationIndex(numPartitions uint64) []options.CreateTableOption {
 		options.WithColumn("uniq", types.Optional(types.TypeUint32)),
 		options.WithColumn("trace_ids", types.Optional(types.TypeString)),
 		options.WithPrimaryKeyColumn("idx_hash", "duration", "rev_start_time", "uniq"),
-		options.WithPartitions(
-			options.WithUniformPartitions(numPartitions),
-		),
-		options.WithPartitioningSettingsObject(partitioningSettings(numPartitions)),
 	}
 }
 
 // TagIndexV2 returns tag_index_v2 table schema
 func TagIndexV2(numPartitions uint64) []options.CreateTableOption {
+	return append(ArchiveTagIndexV2(), partitions(numPartitions)...)
+}
+
+// ArchiveTagIndexV2 returns archive_idx_tag_v2 table schema
+func ArchiveTagIndexV2() []options.CreateTableOption {
 	return []options.CreateTableOption{
 		options.WithColumn("idx_hash", types.Optional(types.TypeUint64)),
 		options.WithColumn("rev_start_time", types.Optional(types.TypeInt64)),
@@ -125,10 +135,6 @@ func TagIndexV2(numPartitions uint64) []options.CreateTableOption {
 		options.WithColumn("uniq", types.Optional(types.TypeUint32)),
 		options.WithColumn("trace_ids", types.Optional(types.TypeString)),
 		options.WithPrimaryKeyColumn("idx_hash", "rev_start_time", "op_hash", "uniq"),
-		options.WithPartitions(
-			options.WithUniformPartitions(numPartitions),
-		),
-		options.WithPartitioningSettingsObject(partitioningSettings(numPartitions)),
 	}
 }
 
@@ -150,6 +156,27 @@ func OperationNamesV2() []options.CreateTableOption {
 	}
 }
 
+// Dependencies returns dependencies table schema, rows are spread by hash of service pair
+// instead of growing bucket time and expire by ts if ttl is set
+func Dependencies() []options.CreateTableOption {
+	res := []options.CreateTableOption{
+		options.WithColumn("idx_hash", types.Optional(types.TypeUint64)),
+		options.WithColumn("ts", types.Optional(types.TypeUint64)),
+		options.WithColumn("parent", types.Optional(types.TypeUTF8)),
+		options.WithColumn("child", types.Optional(types.TypeUTF8)),
+		options.WithColumn("source", types.Optional(types.TypeUTF8)),
+		options.WithColumn("uniq", types.Optional(types.TypeUint32)),
+		options.WithColumn("call_count", types.Optional(types.TypeUint64)),
+		options.WithPrimaryKeyColumn("idx_hash", "ts", "parent", "child", "source", "uniq"),
+	}
+	if ttl := viper.GetDuration(db.KeyYDBDependenciesTTL); ttl > 0 {
+		res = append(res, options.WithTimeToLiveSettings(
+			options.NewTTLSettings().ColumnNanoseconds("ts").ExpireAfter(ttl),
+		))
+	}
+	return res
+}
+
 func Partitions() []options.CreateTableOption {
 	return []options.CreateTableOption{
 		options.WithColumn("part_date", types.Optional(types.TypeUTF8)),
@@ -159,6 +186,15 @@ func Partitions() []options.CreateTableOption {
 	}
 }
 
+func partitions(numPartitions uint64) []options.CreateTableOption {
+	return []options.CreateTableOption{
+		options.WithPartitions(
+			options.WithUniformPartitions(numPartitions),
+		),
+		options.WithPartitioningSettingsObject(partitioningSettings(numPartitions)),
+	}
+}
+
 func partitioningSettings(numPartitions uint64) (settings options.PartitioningSettings) {
 	settings = options.PartitioningSettings{
 		PartitioningBySize: options.FeatureEnabled,
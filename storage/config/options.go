@@ -4,12 +4,19 @@ import (
 	"time"
 
 	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/ratelimit"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/redaction"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/sampling"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer"
 )
 
 type Options struct {
-	BufferSize   int
-	BatchSize    int
-	BatchWorkers int
+	BufferSize    int
+	BatchSize     int
+	BatchWorkers  int
+	BatchBytes    int
+	FlushInterval time.Duration
 
 	IndexerBufferSize int
 	IndexerMaxTraces  int
@@ -18,13 +25,69 @@ type Options struct {
 	DbAddress string
 	DbPath    schema.DbPath
 
+	// SecondaryDbAddress enables dual-write of spans to secondary database
+	SecondaryDbAddress string
+	SecondaryDbPath    schema.DbPath
+
 	PoolSize            int
 	QueryCacheSize      int
 	ConnectTimeout      time.Duration
 	WriteTimeout        time.Duration
 	RetryAttemptTimeout time.Duration
 	WriteSvcOpCacheSize int // cache size for svc/operation index writer
+	WriteNamesInterval  time.Duration
 	WriteMaxSpanAge     time.Duration
+	WriteMaxFutureSkew  time.Duration
+	WriteFutureSkew     writer.FutureSkewPolicy
+	WriteStreaming      bool
+
+	DependenciesEnabled  bool
+	DependenciesWindow   time.Duration
+	DependenciesMaxSpans int
+	DependenciesMaxLinks int
+
+	WriterShutdownTimeout time.Duration
+
+	CreatePartitions         bool
+	CreatePartitionsMaxAge   time.Duration
+	CreatePartitionsMaxAhead time.Duration
+	PartitionCounts          map[string]uint64
+
+	DedupWindow   time.Duration
+	DedupMaxSpans int
+	DedupSpans    bool
+
+	TagPolicyFile           string
+	TagPolicyReloadInterval time.Duration
+
+	SamplingEnabled bool
+	Sampling        sampling.Options
+
+	Redaction redaction.Options
+
+	RateLimitEnabled bool
+	RateLimit        ratelimit.Options
+
+	SpanMaxTagValueLength int
+	SpanMaxTags           int
+	SpanMaxLogs           int
+	SpanMaxBytes          int
+
+	WriterOverflow               batch.OverflowPolicy
+	WriterOverflowTimeout        time.Duration
+	ArchiveWriterOverflow        batch.OverflowPolicy
+	ArchiveWriterOverflowTimeout time.Duration
+
+	RetryMaxAttempts     int
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+	RetryQueueSize       int
+
+	SpoolDir          string
+	SpoolMaxSize      int64
+	SpoolSegmentSize  int64
+	SpoolSync         string
+	SpoolSyncInterval time.Duration
 
 	ReadTimeout       time.Duration
 	ReadQueryParallel int
@@ -0,0 +1,73 @@
+package dependencystore
+
+import (
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// Link is a caller/callee service pair
+type Link struct {
+	Parent string
+	Child  string
+}
+
+// ParentSpanID returns span id of the first CHILD_OF reference within the same trace
+func ParentSpanID(span *model.Span) (model.SpanID, bool) {
+	for _, ref := range span.References {
+		if ref.RefType == model.ChildOf && ref.TraceID == span.TraceID {
+			return ref.SpanID, true
+		}
+	}
+	return 0, false
+}
+
+// SharedSpanLink returns client->server link for zipkin-style spans sharing the same span id
+func SharedSpanLink(a, b *model.Span) (Link, bool) {
+	svcA, svcB := a.GetProcess().GetServiceName(), b.GetProcess().GetServiceName()
+	if svcA == svcB {
+		return Link{}, false
+	}
+	switch {
+	case a.IsRPCClient() && b.IsRPCServer():
+		return Link{Parent: svcA, Child: svcB}, true
+	case a.IsRPCServer() && b.IsRPCClient():
+		return Link{Parent: svcB, Child: svcA}, true
+	}
+	return Link{}, false
+}
+
+// AddTraceLinks derives service links from complete trace and adds call counts to links
+func AddTraceLinks(spans []*model.Span, links map[Link]uint64) {
+	byID := make(map[model.SpanID]*model.Span, len(spans))
+	shared := make(map[model.SpanID]struct{})
+	for _, span := range spans {
+		if other, ok := byID[span.SpanID]; ok {
+			if link, ok := SharedSpanLink(other, span); ok {
+				links[link]++
+				shared[span.SpanID] = struct{}{}
+			}
+			if !span.IsRPCServer() {
+				continue
+			}
+		}
+		// server side of a shared span is the parent for its children
+		byID[span.SpanID] = span
+	}
+	for _, span := range spans {
+		if _, ok := shared[span.SpanID]; ok && span.IsRPCServer() {
+			// server half of a shared span has already been linked to its client
+			continue
+		}
+		parentID, ok := ParentSpanID(span)
+		if !ok {
+			continue
+		}
+		parent, ok := byID[parentID]
+		if !ok || parent == span {
+			continue
+		}
+		parentSvc, childSvc := parent.GetProcess().GetServiceName(), span.GetProcess().GetServiceName()
+		if parentSvc != childSvc {
+			links[Link{Parent: parentSvc, Child: childSvc}]++
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package dependencystore
+
+import (
+	"testing"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTraceLinks(t *testing.T) {
+	traceID := model.NewTraceID(1, 42)
+	newSpan := func(id, parent uint64, svc, kind string) *model.Span {
+		s := &model.Span{
+			TraceID: traceID,
+			SpanID:  model.NewSpanID(id),
+			Process: model.NewProcess(svc, nil),
+		}
+		if parent != 0 {
+			s.References = []model.SpanRef{model.NewChildOfRef(traceID, model.NewSpanID(parent))}
+		}
+		if kind != "" {
+			s.Tags = []model.KeyValue{model.String("span.kind", kind)}
+		}
+		return s
+	}
+
+	t.Run("child_of", func(t *testing.T) {
+		links := map[Link]uint64{}
+		AddTraceLinks([]*model.Span{
+			newSpan(1, 0, "frontend", ""),
+			newSpan(2, 1, "frontend", "client"),
+			newSpan(3, 2, "backend", "server"),
+			newSpan(4, 3, "backend", ""),
+			newSpan(5, 3, "db", ""),
+		}, links)
+		assert.Equal(t, map[Link]uint64{
+			{Parent: "frontend", Child: "backend"}: 1,
+			{Parent: "backend", Child: "db"}:       1,
+		}, links)
+	})
+	t.Run("shared_span", func(t *testing.T) {
+		links := map[Link]uint64{}
+		AddTraceLinks([]*model.Span{
+			newSpan(1, 0, "frontend", ""),
+			newSpan(2, 1, "frontend", "client"),
+			newSpan(2, 1, "backend", "server"),
+			newSpan(3, 2, "backend", ""),
+		}, links)
+		assert.Equal(t, map[Link]uint64{
+			{Parent: "frontend", Child: "backend"}: 1,
+		}, links)
+	})
+}
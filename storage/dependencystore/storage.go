@@ -2,17 +2,91 @@ package dependencystore
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/jaegertracing/jaeger/model"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/queries"
 )
 
+var _ dependencystore.Reader = (*DependencyStore)(nil)
+
 // DependencyStore handles read/writes dependencies to YDB
-type DependencyStore struct{}
+type DependencyStore struct {
+	pool         table.Client
+	opts         Options
+	logger       *zap.Logger
+	jaegerLogger hclog.Logger
+}
+
+type Options struct {
+	DbPath      schema.DbPath
+	ReadTimeout time.Duration
+}
+
+// NewDependencyStore returns a new DependencyStore.
+func NewDependencyStore(pool table.Client, opts Options, logger *zap.Logger, jaegerLogger hclog.Logger) *DependencyStore {
+	return &DependencyStore{
+		pool:         pool,
+		opts:         opts,
+		logger:       logger,
+		jaegerLogger: jaegerLogger,
+	}
+}
+
+// GetDependencies returns service links aggregated over buckets within [endTs - lookback, endTs]
+func (s *DependencyStore) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	if s.opts.ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.ReadTimeout)
+		defer cancel()
+	}
 
-// GetDependencies should return dependency data from YDB, but it's not stored there, so we return nothing
-func (DependencyStore) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+	result := make([]model.DependencyLink, 0)
+	err := s.pool.Do(ctx, func(ctx context.Context, session table.Session) error {
+		result = result[:0]
+		res, err := session.StreamExecuteScanQuery(
+			ctx,
+			queries.BuildQuery("query-dependencies", s.opts.DbPath),
+			table.NewQueryParameters(
+				table.ValueParam("$ts_min", types.Uint64Value(uint64(dbmodel.DependencyBucket(endTs.Add(-lookback))))),
+				table.ValueParam("$ts_max", types.Uint64Value(uint64(endTs.UnixNano()))),
+			),
+		)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = res.Close()
+		}()
+		for res.NextResultSet(ctx) {
+			for res.NextRow() {
+				link := model.DependencyLink{
+					Source: model.JaegerDependencyLinkSource,
+				}
+				if err := res.ScanWithDefaults(&link.Parent, &link.Child, &link.CallCount); err != nil {
+					return fmt.Errorf("scan fail: %w", err)
+				}
+				result = append(result, link)
+			}
+		}
+		return res.Err()
+	})
+	if err != nil {
+		s.logger.Error("Failed to read dependencies", zap.Error(err))
+		s.jaegerLogger.Error(
+			"Failed to read dependencies",
+			"error", err,
+		)
+		return nil, err
+	}
+	return result, nil
 }
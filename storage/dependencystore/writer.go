@@ -0,0 +1,79 @@
+package dependencystore
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+	wmetrics "github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer/metrics"
+)
+
+const (
+	tblDependencies = "dependencies"
+)
+
+// Writer saves aggregated dependency links to YDB
+type Writer struct {
+	pool    table.Client
+	opts    WriterOptions
+	metrics *wmetrics.WriteMetrics
+}
+
+type WriterOptions struct {
+	DbPath schema.DbPath
+	// Source is saved with records, dbmodel.DependencySourceStream if not set
+	Source              string
+	WriteTimeout        time.Duration
+	RetryAttemptTimeout time.Duration
+}
+
+// NewWriter creates dependency links writer
+func NewWriter(pool table.Client, mf metrics.Factory, opts WriterOptions) *Writer {
+	if opts.Source == "" {
+		opts.Source = dbmodel.DependencySourceStream
+	}
+	return &Writer{
+		pool:    pool,
+		opts:    opts,
+		metrics: wmetrics.NewWriteMetrics(mf, tblDependencies),
+	}
+}
+
+// WriteLinks upserts call counts into bucket containing ts.
+// Rows with the same uniq value overwrite each other, so streaming writers should use random uniq
+// while batch jobs use a fixed one to stay idempotent. Reader counts only job records
+// of buckets which have them, so streaming and batch records are never summed up.
+func (w *Writer) WriteLinks(ctx context.Context, ts time.Time, uniq uint32, links map[Link]uint64) error {
+	if len(links) == 0 {
+		return nil
+	}
+	bucket := dbmodel.DependencyBucket(ts)
+	rows := make([]types.Value, 0, len(links))
+	for link, cnt := range links {
+		rec := dbmodel.DependencyLink{
+			Ts:        uint64(bucket),
+			Parent:    link.Parent,
+			Child:     link.Child,
+			Source:    w.opts.Source,
+			Uniq:      uniq,
+			CallCount: cnt,
+		}
+		rows = append(rows, rec.StructValue())
+	}
+
+	if w.opts.WriteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.opts.WriteTimeout)
+		defer cancel()
+	}
+	t := time.Now()
+	err := db.UpsertData(ctx, w.pool, w.opts.DbPath.FullTable(tblDependencies), types.ListValue(rows...), w.opts.RetryAttemptTimeout)
+	w.metrics.Emit(err, time.Since(t), len(rows))
+	return err
+}
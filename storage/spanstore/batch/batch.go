@@ -2,16 +2,22 @@ package batch
 
 type batch struct {
 	items []interface{}
+	bytes int
 }
 
 func newBatch(cnt int) *batch {
 	return &batch{items: make([]interface{}, 0, cnt)}
 }
 
-func (b *batch) Append(item interface{}) {
+func (b *batch) Append(item interface{}, size int) {
 	b.items = append(b.items, item)
+	b.bytes += size
 }
 
 func (b *batch) Len() int {
 	return len(b.items)
 }
+
+func (b *batch) Bytes() int {
+	return b.bytes
+}
@@ -1,52 +1,142 @@
 package batch
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/uber/jaeger-lib/metrics"
 )
 
 const (
-	defaultBufferSize = 2000
+	defaultBufferSize    = 2000
+	defaultFlushInterval = time.Second
 )
 
-var ErrOverflow = errors.New("writer buffer overflow")
+var (
+	ErrOverflow = errors.New("writer buffer overflow")
+	ErrClosed   = errors.New("writer queue is closed")
+)
+
+// OverflowPolicy controls what Add does when buffer is full
+type OverflowPolicy string
+
+const (
+	// OverflowDrop drops item immediately
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowBlock waits for free space up to BlockTimeout or context deadline
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowReject returns error immediately, caller is expected to retry
+	OverflowReject OverflowPolicy = "reject"
+)
+
+// ParseOverflowPolicy validates policy name, empty name means OverflowDrop
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch p := OverflowPolicy(s); p {
+	case "":
+		return OverflowDrop, nil
+	case OverflowDrop, OverflowBlock, OverflowReject:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy '%s'", s)
+	}
+}
 
 // Queue represents queue of message batches
 type Queue struct {
-	opts        Options
-	inFlight    chan *batch
-	itemBuffer  chan interface{}
-	writer      Writer
-	dropCounter metrics.Counter
-	doneCh      chan struct{}
+	opts          Options
+	inFlight      chan *batch
+	itemBuffer    chan interface{}
+	writer        Writer
+	dropCounter   metrics.Counter
+	rejectCounter metrics.Counter
+	doneCh        chan struct{}
+	wg            sync.WaitGroup
+	// pending counts items added but not passed to writer yet
+	pending atomic.Int64
+	// mu makes closing atomic with enqueue, Add holds it for reading
+	mu     sync.RWMutex
+	closed bool
+}
+
+// DrainStats reports how many items were written or lost on Close
+type DrainStats struct {
+	Flushed int64
+	Lost    int64
+}
+
+func (s DrainStats) Add(other DrainStats) DrainStats {
+	return DrainStats{Flushed: s.Flushed + other.Flushed, Lost: s.Lost + other.Lost}
 }
 
 type Writer interface {
 	WriteItems(_ []interface{})
 }
 
+// Sizer is implemented by writers which can estimate serialized item size,
+// Options.BatchBytes is ignored for other writers
+type Sizer interface {
+	ItemSize(item interface{}) int
+}
+
 type Options struct {
 	BufferSize   int
 	BatchSize    int
 	BatchWorkers int
+	// BatchBytes limits estimated batch size in bytes, zero means no limit
+	BatchBytes int
+	// FlushInterval is the maximum time incomplete batch waits for more items
+	FlushInterval time.Duration
+
+	Overflow     OverflowPolicy
+	BlockTimeout time.Duration
+}
+
+// Enqueue puts item to ch according to overflow policy, returns false if there's no space left
+func Enqueue[T any](ctx context.Context, ch chan<- T, item T, opts Options) bool {
+	select {
+	case ch <- item:
+		return true
+	default:
+	}
+	if opts.Overflow != OverflowBlock {
+		return false
+	}
+	if opts.BlockTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.BlockTimeout)
+		defer cancel()
+	}
+	select {
+	case ch <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 func NewQueue(opts Options, mf metrics.Factory, writer Writer) *Queue {
 	if opts.BufferSize <= 0 {
 		opts.BufferSize = defaultBufferSize
 	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
 	doneCh := make(chan struct{})
 	q := &Queue{
-		opts:        opts,
-		inFlight:    make(chan *batch, 10),
-		itemBuffer:  make(chan interface{}, opts.BufferSize),
-		writer:      writer,
-		dropCounter: mf.Counter(metrics.Options{Name: "dropped"}),
-		doneCh:      doneCh,
+		opts:          opts,
+		inFlight:      make(chan *batch, 10),
+		itemBuffer:    make(chan interface{}, opts.BufferSize),
+		writer:        writer,
+		dropCounter:   mf.Counter(metrics.Options{Name: "dropped"}),
+		rejectCounter: mf.Counter(metrics.Options{Name: "rejected"}),
+		doneCh:        doneCh,
 	}
 
+	q.wg.Add(1 + q.opts.BatchWorkers)
 	go q.inputProcessor()
 	for i := 0; i < q.opts.BatchWorkers; i++ {
 		go q.batchProcessor()
@@ -56,49 +146,133 @@ func NewQueue(opts Options, mf metrics.Factory, writer Writer) *Queue {
 }
 
 func (w *Queue) Add(item interface{}) error {
+	return w.AddContext(context.Background(), item)
+}
+
+// AddContext is like Add, but with OverflowBlock policy it waits no longer than ctx allows
+func (w *Queue) AddContext(ctx context.Context, item interface{}) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropCounter.Inc(1)
+		return ErrClosed
+	}
+	w.pending.Add(1)
+	if Enqueue(ctx, w.itemBuffer, item, w.opts) {
+		return nil
+	}
+	w.pending.Add(-1)
+	if w.opts.Overflow == OverflowDrop || w.opts.Overflow == "" {
+		w.dropCounter.Inc(1)
+	} else {
+		w.rejectCounter.Inc(1)
+	}
+	return ErrOverflow
+}
+
+// AddWait waits for free space regardless of overflow policy, it's meant for saving state on shutdown
+func (w *Queue) AddWait(ctx context.Context, item interface{}) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropCounter.Inc(1)
+		return ErrClosed
+	}
+	w.pending.Add(1)
 	select {
 	case w.itemBuffer <- item:
 		return nil
-	default:
+	case <-ctx.Done():
+		w.pending.Add(-1)
 		w.dropCounter.Inc(1)
 		return ErrOverflow
 	}
 }
 
 func (w *Queue) inputProcessor() {
+	defer w.wg.Done()
+	defer close(w.inFlight)
+	sizer, _ := w.writer.(Sizer)
+	if w.opts.BatchBytes <= 0 {
+		sizer = nil
+	}
 	batch := newBatch(w.opts.BatchSize)
-	flushTimer := time.NewTimer(time.Second)
+	push := func() {
+		if batch.Len() > 0 {
+			w.inFlight <- batch
+			batch = newBatch(w.opts.BatchSize)
+		}
+	}
+	add := func(item interface{}) {
+		size := 0
+		if sizer != nil {
+			size = sizer.ItemSize(item)
+			// item which doesn't fit goes to the next batch, big item gets batch of its own
+			if batch.Bytes()+size > w.opts.BatchBytes {
+				push()
+			}
+		}
+		batch.Append(item, size)
+		if batch.Len() >= w.opts.BatchSize || (sizer != nil && batch.Bytes() >= w.opts.BatchBytes) {
+			push()
+		}
+	}
+
+	flushTimer := time.NewTimer(w.opts.FlushInterval)
+	defer flushTimer.Stop()
 	for {
 		select {
 		case <-w.doneCh:
-			return
-		case item := <-w.itemBuffer:
-			batch.Append(item)
-			if batch.Len() >= w.opts.BatchSize {
-				w.inFlight <- batch
-				batch = newBatch(w.opts.BatchSize)
+			// pass everything buffered to workers
+			for {
+				select {
+				case item := <-w.itemBuffer:
+					add(item)
+				default:
+					push()
+					return
+				}
 			}
+		case item := <-w.itemBuffer:
+			add(item)
 		case <-flushTimer.C:
-			flushTimer.Reset(time.Second)
-			if batch.Len() > 0 {
-				w.inFlight <- batch
-				batch = newBatch(w.opts.BatchSize)
-			}
+			flushTimer.Reset(w.opts.FlushInterval)
+			push()
 		}
 	}
 }
 
 func (w *Queue) batchProcessor() {
-	for {
-		select {
-		case <-w.doneCh:
-			return
-		case b := <-w.inFlight:
-			w.writer.WriteItems(b.items)
-		}
+	defer w.wg.Done()
+	for b := range w.inFlight {
+		w.writer.WriteItems(b.items)
+		w.pending.Add(-int64(b.Len()))
 	}
 }
 
-func (w *Queue) Close() {
-	w.doneCh <- struct{}{}
+// Close flushes buffered items and stops workers.
+// If ctx is done before all items are written, Close returns and remaining items are reported as lost.
+func (w *Queue) Close(ctx context.Context) DrainStats {
+	// waits for Add calls in progress, so no item is enqueued after input is drained
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return DrainStats{}
+	}
+	w.closed = true
+	w.mu.Unlock()
+	pending := w.pending.Load()
+	close(w.doneCh)
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	lost := w.pending.Load()
+	return DrainStats{Flushed: pending - lost, Lost: lost}
 }
@@ -0,0 +1,146 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+func TestEnqueue(t *testing.T) {
+	ch := make(chan int, 1)
+	assert.True(t, Enqueue(context.Background(), ch, 1, Options{Overflow: OverflowDrop}))
+	assert.False(t, Enqueue(context.Background(), ch, 2, Options{Overflow: OverflowDrop}))
+	assert.False(t, Enqueue(context.Background(), ch, 2, Options{Overflow: OverflowReject}))
+
+	ts := time.Now()
+	assert.False(t, Enqueue(context.Background(), ch, 2, Options{Overflow: OverflowBlock, BlockTimeout: 10 * time.Millisecond}))
+	assert.GreaterOrEqual(t, time.Since(ts), 10*time.Millisecond)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-ch
+	}()
+	assert.True(t, Enqueue(context.Background(), ch, 2, Options{Overflow: OverflowBlock, BlockTimeout: time.Second}))
+	assert.Equal(t, 2, <-ch)
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	p, err := ParseOverflowPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, OverflowDrop, p)
+	p, err = ParseOverflowPolicy("block")
+	assert.NoError(t, err)
+	assert.Equal(t, OverflowBlock, p)
+	_, err = ParseOverflowPolicy("wait")
+	assert.Error(t, err)
+}
+
+type testWriter struct {
+	mu    sync.Mutex
+	items []interface{}
+	delay time.Duration
+}
+
+func (w *testWriter) WriteItems(items []interface{}) {
+	time.Sleep(w.delay)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.items = append(w.items, items...)
+}
+
+func TestQueue_Close(t *testing.T) {
+	w := &testWriter{}
+	q := NewQueue(Options{BufferSize: 100, BatchSize: 10, BatchWorkers: 2}, metrics.NullFactory, w)
+	for i := 0; i < 25; i++ {
+		assert.NoError(t, q.Add(i))
+	}
+	stats := q.Close(context.Background())
+	assert.Equal(t, DrainStats{Flushed: 25}, stats)
+	assert.Len(t, w.items, 25)
+	assert.ErrorIs(t, q.Add(26), ErrClosed)
+	assert.Equal(t, DrainStats{}, q.Close(context.Background()))
+}
+
+func TestQueue_CloseConcurrentAdd(t *testing.T) {
+	w := &testWriter{}
+	q := NewQueue(Options{BufferSize: 1000, BatchSize: 10, BatchWorkers: 2}, metrics.NullFactory, w)
+	var (
+		wg    sync.WaitGroup
+		added atomic.Int64
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				switch q.Add(1) {
+				case nil:
+					added.Add(1)
+				case ErrClosed:
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	stats := q.Close(context.Background())
+	wg.Wait()
+	// items added before Close are written either way, and none is left in buffer after it
+	assert.Zero(t, stats.Lost)
+	assert.GreaterOrEqual(t, stats.Flushed, int64(0))
+	assert.Equal(t, int(added.Load()), len(w.items))
+}
+
+func TestQueue_CloseDeadline(t *testing.T) {
+	w := &testWriter{delay: time.Second}
+	q := NewQueue(Options{BufferSize: 100, BatchSize: 10, BatchWorkers: 1}, metrics.NullFactory, w)
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, q.Add(i))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	stats := q.Close(ctx)
+	assert.Equal(t, DrainStats{Lost: 20}, stats)
+}
+
+type sizedWriter struct {
+	mu      sync.Mutex
+	batches [][]interface{}
+}
+
+func (w *sizedWriter) WriteItems(items []interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches = append(w.batches, items)
+}
+
+func (w *sizedWriter) ItemSize(item interface{}) int {
+	return item.(int)
+}
+
+func TestQueue_BatchBytes(t *testing.T) {
+	w := &sizedWriter{}
+	q := NewQueue(Options{BufferSize: 100, BatchSize: 10, BatchWorkers: 1, BatchBytes: 100, FlushInterval: time.Hour}, metrics.NullFactory, w)
+	for _, size := range []int{40, 40, 40, 200, 10, 90, 5} {
+		assert.NoError(t, q.Add(size))
+	}
+	q.Close(context.Background())
+	assert.Equal(t, [][]interface{}{{40, 40}, {40}, {200}, {10, 90}, {5}}, w.batches)
+}
+
+func TestQueue_FlushInterval(t *testing.T) {
+	w := &sizedWriter{}
+	q := NewQueue(Options{BufferSize: 100, BatchSize: 10, BatchWorkers: 1, FlushInterval: 10 * time.Millisecond}, metrics.NullFactory, w)
+	assert.NoError(t, q.Add(1))
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return len(w.batches) == 1
+	}, time.Second, time.Millisecond)
+	q.Close(context.Background())
+}
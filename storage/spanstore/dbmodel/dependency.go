@@ -0,0 +1,44 @@
+package dbmodel
+
+import (
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// DependencyBucketStep is the time granularity of dependencies table rows
+const DependencyBucketStep = time.Hour
+
+// Sources of dependency records. Job rebuilds whole buckets from stored traces,
+// so its records replace ones aggregated by span writer when bucket is read.
+const (
+	DependencySourceStream = "stream"
+	DependencySourceJob    = "job"
+)
+
+// DependencyLink represents db-serializable dependency record
+type DependencyLink struct {
+	Ts        uint64
+	Parent    string
+	Child     string
+	Source    string
+	Uniq      uint32
+	CallCount uint64
+}
+
+// DependencyBucket returns bucket timestamp for dependency records written at t
+func DependencyBucket(t time.Time) int64 {
+	return t.UTC().Truncate(DependencyBucketStep).UnixNano()
+}
+
+func (l *DependencyLink) StructValue() types.Value {
+	return types.StructValue(
+		types.StructFieldValue("idx_hash", types.OptionalValue(types.Uint64Value(HashData(l.Parent, l.Child)))),
+		types.StructFieldValue("ts", types.OptionalValue(types.Uint64Value(l.Ts))),
+		types.StructFieldValue("parent", types.OptionalValue(types.TextValue(l.Parent))),
+		types.StructFieldValue("child", types.OptionalValue(types.TextValue(l.Child))),
+		types.StructFieldValue("source", types.OptionalValue(types.TextValue(l.Source))),
+		types.StructFieldValue("uniq", types.OptionalValue(types.Uint32Value(l.Uniq))),
+		types.StructFieldValue("call_count", types.OptionalValue(types.Uint64Value(l.CallCount))),
+	)
+}
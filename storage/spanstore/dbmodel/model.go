@@ -20,15 +20,10 @@ type Span struct {
 	Extra         []byte
 }
 
-// FromDomain converts plugin model to db model or returns error
+// FromDomain converts plugin model to db model or returns error,
+// OTLP fields attached to span by AttachOtlp are stored with it
 func FromDomain(span *model.Span) (*Span, error) {
-	spanData := SpanData{
-		Process:    span.Process,
-		Tags:       span.Tags,
-		Logs:       span.Logs,
-		References: span.References,
-	}
-	extra, err := proto.Marshal(&spanData)
+	extra, err := proto.Marshal(newSpanData(span))
 	if err != nil {
 		return nil, fmt.Errorf("dbSpan.Extra marshal error: %w", err)
 	}
@@ -45,12 +40,44 @@ func FromDomain(span *model.Span) (*Span, error) {
 	return dbSpan, nil
 }
 
-// ToDomain converts db model to plugin model
+// fixed size columns of Span
+const spanFixedSize = 8*3 + 4 + 8*2
+
+// EstimateSize returns size of db row for span without marshalling it,
+// Extra length is exactly what FromDomain would produce
+func EstimateSize(span *model.Span) int {
+	return spanFixedSize + len(span.OperationName) + newSpanData(span).Size()
+}
+
+func newSpanData(span *model.Span) *SpanData {
+	otlp, tags := splitOtlp(span.Tags)
+	spanData := &SpanData{
+		Process:    span.Process,
+		Tags:       tags,
+		Logs:       span.Logs,
+		References: span.References,
+		Warnings:   span.Warnings,
+	}
+	if otlp != nil {
+		spanData.Version = EncodingVersionOtlp
+		spanData.Otlp = otlp
+	}
+	return spanData
+}
+
+// ToDomain converts db model to plugin model, OTLP fields are left out
 func ToDomain(dbSpan *Span) (*model.Span, error) {
+	span, _, err := ToDomainOtlp(dbSpan)
+	return span, err
+}
+
+// ToDomainOtlp converts db model to plugin model and returns OTLP fields of span,
+// which are nil for spans written without them
+func ToDomainOtlp(dbSpan *Span) (*model.Span, *OtlpSpan, error) {
 	spanData := SpanData{}
 	err := proto.Unmarshal(dbSpan.Extra, &spanData)
 	if err != nil {
-		return nil, fmt.Errorf("dbSpan.Extra unmarshal error: %w", err)
+		return nil, nil, fmt.Errorf("dbSpan.Extra unmarshal error: %w", err)
 	}
 
 	span := &model.Span{
@@ -64,6 +91,7 @@ func ToDomain(dbSpan *Span) (*model.Span, error) {
 		References:    spanData.References,
 		Tags:          spanData.Tags,
 		Logs:          spanData.Logs,
+		Warnings:      spanData.Warnings,
 	}
-	return span, nil
+	return span, spanData.Otlp, nil
 }
@@ -36,12 +36,14 @@ func TestEncDec(t *testing.T) {
 				Fields:    []model.KeyValue{model.String("log2", "record2")},
 			},
 		},
+		Warnings: []string{"warning"},
 	}
 
 	dbSpan, err := FromDomain(span)
 	if !assert.NoError(t, err) {
 		return
 	}
+	assert.Equal(t, spanFixedSize+len(dbSpan.OperationName)+len(dbSpan.Extra), EstimateSize(span))
 	resultSpan, err := ToDomain(dbSpan)
 	if !assert.NoError(t, err) {
 		return
@@ -0,0 +1,53 @@
+package dbmodel
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// EncodingVersionOtlp is version of SpanData which keeps OTLP span, spans without OTLP fields keep zero version
+const EncodingVersionOtlp = 1
+
+// TagOtlpSpan carries marshalled OtlpSpan in Jaeger view of span from OTLP translation to storage and back,
+// FromDomain moves it to SpanData, so it's never stored as a tag. Binary tags aren't indexed.
+const TagOtlpSpan = "internal.otlp.span"
+
+// AttachOtlp adds OTLP fields to Jaeger view of span, they go through writer with it and are stored by FromDomain
+func AttachOtlp(span *model.Span, otlp *OtlpSpan) error {
+	data, err := proto.Marshal(otlp)
+	if err != nil {
+		return err
+	}
+	span.Tags = append(span.Tags, model.Binary(TagOtlpSpan, data))
+	return nil
+}
+
+// DetachOtlp removes OTLP fields attached to span, nil is returned if span has none or they can't be decoded
+func DetachOtlp(span *model.Span) *OtlpSpan {
+	otlp, tags := splitOtlp(span.Tags)
+	span.Tags = tags
+	return otlp
+}
+
+// splitOtlp returns OTLP fields attached to tags and the rest of tags, tags aren't modified
+func splitOtlp(tags []model.KeyValue) (*OtlpSpan, []model.KeyValue) {
+	i := -1
+	for j := range tags {
+		if tags[j].Key == TagOtlpSpan && tags[j].VType == model.BinaryType {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return nil, tags
+	}
+	rest := make([]model.KeyValue, 0, len(tags)-1)
+	rest = append(rest, tags[:i]...)
+	rest = append(rest, tags[i+1:]...)
+	otlp := &OtlpSpan{}
+	if err := proto.Unmarshal(tags[i].VBinary, otlp); err != nil {
+		// e.g. cut by tag value length limit, Jaeger view of span is still complete
+		return nil, rest
+	}
+	return otlp, rest
+}
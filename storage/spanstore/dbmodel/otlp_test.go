@@ -0,0 +1,91 @@
+package dbmodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/testutil"
+)
+
+func testOtlpSpan() *OtlpSpan {
+	return &OtlpSpan{
+		Scope: &InstrumentationScope{
+			Name:       "io.opentelemetry.http",
+			Version:    "1.2.0",
+			Attributes: []model.KeyValue{model.String("a", "b")},
+		},
+		Status:     &Status{Code: Status_STATUS_CODE_ERROR, Message: "timeout"},
+		TraceState: "vendor=value",
+		EventNames: []string{"", "exception"},
+		Links: []Link{
+			{TraceIdHigh: 1, TraceIdLow: 2, SpanId: 3, TraceState: "vendor=link", Attributes: []model.KeyValue{model.Int64("n", 1)}},
+		},
+	}
+}
+
+func TestOtlpRoundTrip(t *testing.T) {
+	span := &model.Span{
+		TraceID:   testutil.GenerateTraceID(),
+		SpanID:    model.NewSpanID(42),
+		StartTime: time.Now().Round(0).UTC(),
+		Process:   model.NewProcess("svc1", nil),
+		Tags:      []model.KeyValue{model.String("k", "v")},
+		Logs: []model.Log{
+			{Timestamp: time.Now().Round(0).UTC(), Fields: []model.KeyValue{model.String("message", "m")}},
+		},
+	}
+	otlp := testOtlpSpan()
+	require.NoError(t, AttachOtlp(span, otlp))
+	tags := span.Tags
+
+	dbSpan, err := FromDomain(span)
+	require.NoError(t, err)
+	assert.Equal(t, tags, span.Tags, "span passed to writer isn't modified")
+	assert.Equal(t, EstimateSize(span), spanFixedSize+len(span.OperationName)+len(dbSpan.Extra))
+
+	spanData := SpanData{}
+	require.NoError(t, proto.Unmarshal(dbSpan.Extra, &spanData))
+	assert.Equal(t, uint32(EncodingVersionOtlp), spanData.Version)
+	assert.Equal(t, []model.KeyValue{model.String("k", "v")}, spanData.Tags)
+
+	resultSpan, resultOtlp, err := ToDomainOtlp(dbSpan)
+	require.NoError(t, err)
+	assert.Equal(t, otlp, resultOtlp)
+	assert.Equal(t, []model.KeyValue{model.String("k", "v")}, resultSpan.Tags)
+
+	require.NoError(t, AttachOtlp(resultSpan, resultOtlp))
+	assert.Equal(t, span, resultSpan)
+	assert.Equal(t, otlp, DetachOtlp(resultSpan))
+	assert.Equal(t, []model.KeyValue{model.String("k", "v")}, resultSpan.Tags)
+}
+
+func TestOtlpBrokenTag(t *testing.T) {
+	span := &model.Span{
+		Process: model.NewProcess("svc1", nil),
+		Tags:    []model.KeyValue{model.String("k", "v"), model.Binary(TagOtlpSpan, []byte{0xff})},
+	}
+	dbSpan, err := FromDomain(span)
+	require.NoError(t, err)
+	resultSpan, otlp, err := ToDomainOtlp(dbSpan)
+	require.NoError(t, err)
+	assert.Nil(t, otlp)
+	assert.Equal(t, []model.KeyValue{model.String("k", "v")}, resultSpan.Tags)
+}
+
+func TestToDomainLegacyEncoding(t *testing.T) {
+	spanData := SpanData{
+		Process: model.NewProcess("svc1", nil),
+		Tags:    []model.KeyValue{model.String("otel.status_code", "ERROR")},
+	}
+	extra, err := proto.Marshal(&spanData)
+	require.NoError(t, err)
+	span, otlp, err := ToDomainOtlp(&Span{Extra: extra})
+	require.NoError(t, err)
+	assert.Nil(t, otlp)
+	assert.Equal(t, spanData.Tags, span.Tags)
+}
@@ -1,7 +1,9 @@
 package indexer
 
 import (
+	"context"
 	"errors"
+	"sync"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/jaegertracing/jaeger/model"
@@ -9,6 +11,7 @@ import (
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
 	"go.uber.org/zap"
 
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
 	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/indexer/index"
 )
 
@@ -17,9 +20,14 @@ const (
 	tblDurationIndex         = "idx_duration"
 	tblServiceNameIndex      = "idx_service_name"
 	tblServiceOperationIndex = "idx_service_op"
+
+	archiveTablePrefix = "archive_"
 )
 
-var ErrOverflow = errors.New("indexer buffer overflow")
+var (
+	ErrOverflow = errors.New("indexer buffer overflow")
+	ErrClosed   = errors.New("indexer is closed")
+)
 
 type Indexer struct {
 	opts         Options
@@ -31,8 +39,18 @@ type Indexer struct {
 	svcWriter      *indexWriter
 	opWriter       *indexWriter
 	durationWriter *indexWriter
+	tagPolicy      *TagPolicySource
+	tagCounters    *tagCounters
 	dropCounter    metrics.Counter
+	rejectCounter  metrics.Counter
 	doneCh         chan struct{}
+	// abortCh stops indexing of buffered spans when Close ctx is done
+	abortCh   chan struct{}
+	stoppedCh chan struct{}
+
+	// mu guards closed, Close waits for AddContext calls in progress
+	mu     sync.RWMutex
+	closed bool
 }
 
 func NewIndexer(pool table.Client, mf metrics.Factory, logger *zap.Logger, jaegerLogger hclog.Logger, opts Options) *Indexer {
@@ -42,14 +60,26 @@ func NewIndexer(pool table.Client, mf metrics.Factory, logger *zap.Logger, jaege
 		jaegerLogger: jaegerLogger,
 		opts:         opts,
 
-		inputItems:  make(chan *model.Span, opts.BufferSize),
-		dropCounter: mf.Counter(metrics.Options{Name: "indexer_dropped"}),
-		doneCh:      doneCh,
+		inputItems:    make(chan *model.Span, opts.BufferSize),
+		dropCounter:   mf.Counter(metrics.Options{Name: "indexer_dropped"}),
+		rejectCounter: mf.Counter(metrics.Options{Name: "indexer_rejected"}),
+		doneCh:        doneCh,
+		abortCh:       make(chan struct{}),
+		stoppedCh:     make(chan struct{}),
+	}
+	indexer.tagPolicy = opts.TagPolicy
+	if indexer.tagPolicy == nil {
+		indexer.tagPolicy = StaticTagPolicy(DefaultTagPolicy())
 	}
-	indexer.tagWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "tag_index"}), logger, jaegerLogger, tblTagIndex, opts)
-	indexer.svcWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "service_name_index"}), logger, jaegerLogger, tblServiceNameIndex, opts)
-	indexer.opWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "service_operation_index"}), logger, jaegerLogger, tblServiceOperationIndex, opts)
-	indexer.durationWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "duration_index"}), logger, jaegerLogger, tblDurationIndex, opts)
+	indexer.tagCounters = newTagCounters(mf.Namespace(metrics.NSOptions{Name: "tag_policy"}))
+	tblPrefix := ""
+	if opts.Archive {
+		tblPrefix = archiveTablePrefix
+	}
+	indexer.tagWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "tag_index"}), logger, jaegerLogger, tblPrefix+tblTagIndex, opts)
+	indexer.svcWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "service_name_index"}), logger, jaegerLogger, tblPrefix+tblServiceNameIndex, opts)
+	indexer.opWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "service_operation_index"}), logger, jaegerLogger, tblPrefix+tblServiceOperationIndex, opts)
+	indexer.durationWriter = newIndexWriter(pool, mf.Namespace(metrics.NSOptions{Name: "duration_index"}), logger, jaegerLogger, tblPrefix+tblDurationIndex, opts)
 
 	go indexer.spanProcessor()
 
@@ -57,45 +87,116 @@ func NewIndexer(pool table.Client, mf metrics.Factory, logger *zap.Logger, jaege
 }
 
 func (w *Indexer) Add(span *model.Span) error {
-	select {
-	case w.inputItems <- span:
+	return w.AddContext(context.Background(), span)
+}
+
+// AddContext follows the same overflow policy as batch.Queue configured by Options.Batch
+func (w *Indexer) AddContext(ctx context.Context, span *model.Span) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropCounter.Inc(1)
+		return ErrClosed
+	}
+	if batch.Enqueue(ctx, w.inputItems, span, w.opts.Batch) {
 		return nil
-	default:
+	}
+	if w.opts.Batch.Overflow == batch.OverflowDrop || w.opts.Batch.Overflow == "" {
 		w.dropCounter.Inc(1)
-		return ErrOverflow
+	} else {
+		w.rejectCounter.Inc(1)
 	}
+	return ErrOverflow
 }
 
 func (w *Indexer) spanProcessor() {
+	defer close(w.stoppedCh)
 	for {
 		select {
 		case <-w.doneCh:
-			return
-		case span := <-w.inputItems:
-			for _, tag := range span.GetTags() {
-				w.processTag(tag, span)
-			}
-			if spanProcess := span.GetProcess(); spanProcess != nil {
-				for _, tag := range spanProcess.GetTags() {
-					w.processTag(tag, span)
+			// index spans left in buffer
+			for {
+				select {
+				case <-w.abortCh:
+					return
+				default:
+				}
+				select {
+				case <-w.abortCh:
+					return
+				case span := <-w.inputItems:
+					w.processSpan(span)
+				default:
+					return
 				}
 			}
-			w.svcWriter.Add(index.NewServiceNameIndex(span), span.TraceID)
-			w.opWriter.Add(index.NewServiceOperationIndex(span), span.TraceID)
-			if span.OperationName != "" {
-				w.durationWriter.Add(index.NewDurationIndex(span, span.OperationName), span.TraceID)
-			}
-			w.durationWriter.Add(index.NewDurationIndex(span, ""), span.TraceID)
+		case span := <-w.inputItems:
+			w.processSpan(span)
 		}
 	}
 }
 
-func (w *Indexer) processTag(kv model.KeyValue, span *model.Span) {
-	if shouldIndexTag(kv) {
+func (w *Indexer) processSpan(span *model.Span) {
+	// policy is taken once per span, so reload doesn't affect half of its tags
+	policy := w.tagPolicy.Policy()
+	service := span.GetProcess().GetServiceName()
+	for _, tag := range span.GetTags() {
+		w.processTag(policy, service, tag, false, span)
+	}
+	if spanProcess := span.GetProcess(); spanProcess != nil {
+		for _, tag := range spanProcess.GetTags() {
+			w.processTag(policy, service, tag, true, span)
+		}
+	}
+	// status and scope are indexed by canonical tags whatever tags sender used
+	for _, tag := range canonicalTags(span) {
+		w.processTag(policy, service, tag, false, span)
+	}
+	w.svcWriter.Add(index.NewServiceNameIndex(span), span.TraceID)
+	w.opWriter.Add(index.NewServiceOperationIndex(span), span.TraceID)
+	if span.OperationName != "" {
+		w.durationWriter.Add(index.NewDurationIndex(span, span.OperationName), span.TraceID)
+	}
+	w.durationWriter.Add(index.NewDurationIndex(span, ""), span.TraceID)
+}
+
+func (w *Indexer) processTag(policy *TagPolicy, service string, kv model.KeyValue, process bool, span *model.Span) {
+	reason := policy.check(service, kv, process)
+	w.tagCounters.inc(kv.Key, reason)
+	if reason == "" {
 		w.tagWriter.Add(index.NewTagIndex(span, kv), span.TraceID)
 	}
 }
 
-func (w *Indexer) Close() {
-	w.doneCh <- struct{}{}
+// DrainStats reports index entries written or lost on Close and spans which weren't indexed at all
+type DrainStats struct {
+	Entries   batch.DrainStats
+	LostSpans int64
+}
+
+// Close indexes buffered spans and flushes index entries to db, spans added after Close are rejected with ErrClosed.
+// Spans still buffered when ctx is done are reported as LostSpans. Index writers are closed after span processor
+// has stopped, so no entry is added to them while they are drained.
+func (w *Indexer) Close(ctx context.Context) DrainStats {
+	var stats DrainStats
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return stats
+	}
+	w.closed = true
+	w.mu.Unlock()
+	close(w.doneCh)
+	select {
+	case <-w.stoppedCh:
+	case <-ctx.Done():
+		close(w.abortCh)
+		// span being indexed is finished, the rest stay in buffer
+		<-w.stoppedCh
+		stats.LostSpans = int64(len(w.inputItems))
+	}
+	for _, iw := range []*indexWriter{w.tagWriter, w.svcWriter, w.opWriter, w.durationWriter} {
+		stats.Entries = stats.Entries.Add(iw.Close(ctx))
+	}
+	return stats
 }
@@ -0,0 +1,36 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/testutil"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+)
+
+func TestIndexer_AddAfterClose(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	w := NewIndexer(nil, mf, testutil.Zap(), testutil.JaegerLogger(), Options{
+		MaxTraces:  10,
+		MaxTTL:     time.Second,
+		BufferSize: 10,
+		Batch: batch.Options{
+			BufferSize:    10,
+			BatchSize:     10,
+			BatchWorkers:  1,
+			FlushInterval: time.Second,
+		},
+	})
+	assert.Equal(t, DrainStats{}, w.Close(context.Background()))
+
+	err := w.Add(&model.Span{TraceID: model.NewTraceID(1, 2), SpanID: 3})
+	assert.ErrorIs(t, err, ErrClosed)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "indexer_dropped", Value: 1})
+	// second Close is no-op
+	assert.Equal(t, DrainStats{}, w.Close(context.Background()))
+}
@@ -15,4 +15,10 @@ type Options struct {
 	Batch               batch.Options
 	WriteTimeout        time.Duration
 	RetryAttemptTimeout time.Duration
+	// Partitions creates partitions missing at write time, optional
+	Partitions *schema.PartitionCreator
+	// Archive makes indexer write to non-partitioned archive_ index tables
+	Archive bool
+	// TagPolicy selects tags to index, DefaultTagPolicy is used if not set
+	TagPolicy *TagPolicySource
 }
@@ -0,0 +1,40 @@
+package indexer
+
+import (
+	"strings"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// Tags of OTLP to Jaeger translation done by Jaeger collector
+const (
+	tagScopeName      = "otel.scope.name"
+	tagScopeVersion   = "otel.scope.version"
+	tagLibraryName    = "otel.library.name" // deprecated name of otel.scope.name
+	tagLibraryVersion = "otel.library.version"
+	tagStatusCode     = "otel.status_code"
+)
+
+// canonicalTags returns instrumentation scope and status of span as canonical tags missing from span,
+// so span is found by them whatever tags sender used
+func canonicalTags(span *model.Span) []model.KeyValue {
+	tags := model.KeyValues(span.GetTags())
+	var res []model.KeyValue
+	add := func(key, legacyKey string) {
+		if _, ok := tags.FindByKey(key); ok {
+			return
+		}
+		if kv, ok := tags.FindByKey(legacyKey); ok && kv.AsString() != "" {
+			res = append(res, model.String(key, kv.AsString()))
+		}
+	}
+	add(tagScopeName, tagLibraryName)
+	add(tagScopeVersion, tagLibraryVersion)
+	// status code is indexed in upper case, e.g. otel.status_code=ERROR
+	if kv, ok := tags.FindByKey(tagStatusCode); ok {
+		if code := strings.ToUpper(kv.AsString()); code != kv.AsString() && (code == "OK" || code == "ERROR") {
+			res = append(res, model.String(tagStatusCode, code))
+		}
+	}
+	return res
+}
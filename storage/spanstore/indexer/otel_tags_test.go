@@ -0,0 +1,29 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalTags(t *testing.T) {
+	span := &model.Span{
+		Tags: []model.KeyValue{
+			model.String(tagLibraryName, "io.opentelemetry.http"),
+			model.String(tagLibraryVersion, "1.1.0"),
+			model.String(tagScopeVersion, "1.2.0"),
+			model.String(tagStatusCode, "error"),
+		},
+	}
+	assert.Equal(t, []model.KeyValue{
+		model.String(tagScopeName, "io.opentelemetry.http"),
+		model.String(tagStatusCode, "ERROR"),
+	}, canonicalTags(span))
+
+	assert.Empty(t, canonicalTags(&model.Span{Tags: []model.KeyValue{
+		model.String(tagScopeName, "lib"),
+		model.String(tagStatusCode, "OK"),
+		model.String("k", "v"),
+	}}))
+}
@@ -0,0 +1,240 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// stopMap keys are never indexed whatever policy says
+var stopMap = map[string]struct{}{
+	"sampler.type":         {},
+	"sampler.param":        {},
+	"internal.span.format": {},
+}
+
+const (
+	// maxCountedTagKeys limits number of keys with own counters, the rest are counted as otherTagKey
+	maxCountedTagKeys = 1000
+	otherTagKey       = "other"
+)
+
+// reasons of skipped tags used as metric label
+const (
+	skipBinary      = "binary"
+	skipStopList    = "stop_list"
+	skipProcess     = "process"
+	skipDeny        = "deny"
+	skipNotAllowed  = "not_allowed"
+	skipValueLength = "value_length"
+)
+
+// TagRulesConfig selects tags to index by key. Allow and Deny are exact keys,
+// AllowRegex and DenyRegex are regular expressions matched against the whole key.
+type TagRulesConfig struct {
+	Allow      []string `mapstructure:"allow"`
+	Deny       []string `mapstructure:"deny"`
+	AllowRegex []string `mapstructure:"allow-regex"`
+	DenyRegex  []string `mapstructure:"deny-regex"`
+	// MaxValueLength skips string tags longer than this, zero means no limit
+	MaxValueLength int `mapstructure:"max-value-length"`
+}
+
+type ServiceTagRulesConfig struct {
+	Service        string `mapstructure:"service"`
+	TagRulesConfig `mapstructure:",squash"`
+}
+
+// TagPolicyConfig is global rules and rules of single services. Tag is skipped if it's denied by global or service
+// rules. If any allow list is set for service, either globally or for service itself, only allowed tags are indexed.
+// Service MaxValueLength overrides global one.
+type TagPolicyConfig struct {
+	TagRulesConfig `mapstructure:",squash"`
+	// ProcessTags is whether process tags are indexed, true if not set
+	ProcessTags *bool                   `mapstructure:"process-tags"`
+	Services    []ServiceTagRulesConfig `mapstructure:"services"`
+}
+
+type tagRules struct {
+	allow          map[string]struct{}
+	deny           map[string]struct{}
+	allowRegex     []*regexp.Regexp
+	denyRegex      []*regexp.Regexp
+	maxValueLength int
+}
+
+func newTagRules(cfg TagRulesConfig) (tagRules, error) {
+	r := tagRules{
+		allow:          make(map[string]struct{}, len(cfg.Allow)),
+		deny:           make(map[string]struct{}, len(cfg.Deny)),
+		maxValueLength: cfg.MaxValueLength,
+	}
+	if cfg.MaxValueLength < 0 {
+		return r, errors.New("max value length can't be negative")
+	}
+	for _, key := range cfg.Allow {
+		r.allow[key] = struct{}{}
+	}
+	for _, key := range cfg.Deny {
+		r.deny[key] = struct{}{}
+	}
+	var err error
+	if r.allowRegex, err = compileKeyRegex(cfg.AllowRegex); err != nil {
+		return r, err
+	}
+	if r.denyRegex, err = compileKeyRegex(cfg.DenyRegex); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+func compileKeyRegex(exprs []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("key regex: %w", err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+func (r tagRules) hasAllowList() bool {
+	return len(r.allow) > 0 || len(r.allowRegex) > 0
+}
+
+func (r tagRules) allows(key string) bool {
+	return matchKey(key, r.allow, r.allowRegex)
+}
+
+func (r tagRules) denies(key string) bool {
+	return matchKey(key, r.deny, r.denyRegex)
+}
+
+func matchKey(key string, keys map[string]struct{}, regex []*regexp.Regexp) bool {
+	if _, ok := keys[key]; ok {
+		return true
+	}
+	for _, re := range regex {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagPolicy decides which span and process tags are written to tag index
+type TagPolicy struct {
+	global      tagRules
+	services    map[string]tagRules
+	processTags bool
+}
+
+// DefaultTagPolicy indexes every tag except binary ones and stop list
+func DefaultTagPolicy() *TagPolicy {
+	p, _ := NewTagPolicy(TagPolicyConfig{})
+	return p
+}
+
+// NewTagPolicy validates config and compiles key regular expressions
+func NewTagPolicy(cfg TagPolicyConfig) (*TagPolicy, error) {
+	global, err := newTagRules(cfg.TagRulesConfig)
+	if err != nil {
+		return nil, err
+	}
+	p := &TagPolicy{
+		global:      global,
+		services:    make(map[string]tagRules, len(cfg.Services)),
+		processTags: cfg.ProcessTags == nil || *cfg.ProcessTags,
+	}
+	for _, svcCfg := range cfg.Services {
+		if svcCfg.Service == "" {
+			return nil, errors.New("service rules: service must be set")
+		}
+		if _, exists := p.services[svcCfg.Service]; exists {
+			return nil, fmt.Errorf("service rules '%s': duplicate service", svcCfg.Service)
+		}
+		rules, err := newTagRules(svcCfg.TagRulesConfig)
+		if err != nil {
+			return nil, fmt.Errorf("service rules '%s': %w", svcCfg.Service, err)
+		}
+		p.services[svcCfg.Service] = rules
+	}
+	return p, nil
+}
+
+// check returns reason why tag isn't indexed or empty string if it should be indexed
+func (p *TagPolicy) check(service string, kv model.KeyValue, process bool) string {
+	if kv.VType == model.ValueType_BINARY {
+		return skipBinary
+	}
+	if process && !p.processTags {
+		return skipProcess
+	}
+	if _, stop := stopMap[kv.Key]; stop {
+		return skipStopList
+	}
+	svc, hasSvc := p.services[service]
+	if p.global.denies(kv.Key) || (hasSvc && svc.denies(kv.Key)) {
+		return skipDeny
+	}
+	if p.global.hasAllowList() || (hasSvc && svc.hasAllowList()) {
+		if !p.global.allows(kv.Key) && !(hasSvc && svc.allows(kv.Key)) {
+			return skipNotAllowed
+		}
+	}
+	maxValueLength := p.global.maxValueLength
+	if hasSvc && svc.maxValueLength > 0 {
+		maxValueLength = svc.maxValueLength
+	}
+	if maxValueLength > 0 && kv.VType == model.ValueType_STRING && len(kv.VStr) > maxValueLength {
+		return skipValueLength
+	}
+	return ""
+}
+
+type tagCounterKey struct {
+	key    string
+	reason string
+}
+
+// tagCounters counts indexed and skipped tags per key, it's used by indexer goroutine only
+type tagCounters struct {
+	mf       metrics.Factory
+	keys     map[string]struct{}
+	counters map[tagCounterKey]metrics.Counter
+}
+
+func newTagCounters(mf metrics.Factory) *tagCounters {
+	return &tagCounters{
+		mf:       mf,
+		keys:     make(map[string]struct{}),
+		counters: make(map[tagCounterKey]metrics.Counter),
+	}
+}
+
+// inc counts indexed tag if reason is empty, otherwise skipped one
+func (c *tagCounters) inc(key, reason string) {
+	if _, ok := c.keys[key]; !ok {
+		if len(c.keys) >= maxCountedTagKeys {
+			key = otherTagKey
+		} else {
+			c.keys[key] = struct{}{}
+		}
+	}
+	k := tagCounterKey{key: key, reason: reason}
+	counter, ok := c.counters[k]
+	if !ok {
+		if reason == "" {
+			counter = c.mf.Counter(metrics.Options{Name: "indexed", Tags: map[string]string{"key": key}})
+		} else {
+			counter = c.mf.Counter(metrics.Options{Name: "skipped", Tags: map[string]string{"key": key, "reason": reason}})
+		}
+		c.counters[k] = counter
+	}
+	counter.Inc(1)
+}
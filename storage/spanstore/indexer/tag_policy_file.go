@@ -0,0 +1,123 @@
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+)
+
+// TagPolicySource keeps current tag policy, which is replaced when policy file changes
+type TagPolicySource struct {
+	current atomic.Pointer[TagPolicy]
+
+	path     string
+	interval time.Duration
+	logger   *zap.Logger
+	reloads  metrics.Counter
+	errors   metrics.Counter
+	modTime  time.Time
+	size     int64
+
+	doneCh    chan struct{}
+	stoppedCh chan struct{}
+}
+
+// StaticTagPolicy returns source which never changes policy
+func StaticTagPolicy(p *TagPolicy) *TagPolicySource {
+	s := &TagPolicySource{}
+	s.current.Store(p)
+	return s
+}
+
+// WatchTagPolicyFile loads TagPolicyConfig from YAML or JSON file and checks file for changes every interval.
+// Error is returned if file can't be loaded on start, invalid file on reload is logged and current policy is kept.
+func WatchTagPolicyFile(path string, interval time.Duration, mf metrics.Factory, logger *zap.Logger) (*TagPolicySource, error) {
+	s := &TagPolicySource{
+		path:      path,
+		interval:  interval,
+		logger:    logger,
+		reloads:   mf.Counter(metrics.Options{Name: "reloads"}),
+		errors:    mf.Counter(metrics.Options{Name: "reload_errors"}),
+		doneCh:    make(chan struct{}),
+		stoppedCh: make(chan struct{}),
+	}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go s.watch()
+	} else {
+		close(s.stoppedCh)
+	}
+	return s, nil
+}
+
+// Policy returns current policy
+func (s *TagPolicySource) Policy() *TagPolicy {
+	return s.current.Load()
+}
+
+// Close stops watching file
+func (s *TagPolicySource) Close() {
+	if s.doneCh == nil {
+		return
+	}
+	close(s.doneCh)
+	<-s.stoppedCh
+}
+
+func (s *TagPolicySource) watch() {
+	defer close(s.stoppedCh)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.doneCh:
+			return
+		case <-ticker.C:
+			changed, err := s.reload()
+			if err != nil {
+				s.errors.Inc(1)
+				s.logger.Error("tag policy reload failed, current policy is kept", zap.String("file", s.path), zap.Error(err))
+				continue
+			}
+			if changed {
+				s.reloads.Inc(1)
+				s.logger.Info("tag policy reloaded", zap.String("file", s.path))
+			}
+		}
+	}
+}
+
+// reload replaces policy if file modification time or size changed since last check,
+// invalid file isn't read again until it changes
+func (s *TagPolicySource) reload() (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("tag policy file: %w", err)
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return false, nil
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	v := viper.New()
+	v.SetConfigFile(s.path)
+	if err = v.ReadInConfig(); err != nil {
+		return false, fmt.Errorf("tag policy file: %w", err)
+	}
+	cfg := TagPolicyConfig{}
+	if err = v.Unmarshal(&cfg); err != nil {
+		return false, fmt.Errorf("tag policy file: %w", err)
+	}
+	p, err := NewTagPolicy(cfg)
+	if err != nil {
+		return false, fmt.Errorf("tag policy file: %w", err)
+	}
+	s.current.Store(p)
+	return true, nil
+}
@@ -0,0 +1,118 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+)
+
+func TestDefaultTagPolicy(t *testing.T) {
+	p := DefaultTagPolicy()
+	assert.Equal(t, "", p.check("svc", model.String("http.url", "/"), false))
+	assert.Equal(t, "", p.check("svc", model.String("hostname", "host"), true))
+	assert.Equal(t, skipBinary, p.check("svc", model.Binary("payload", []byte{1}), false))
+	assert.Equal(t, skipStopList, p.check("svc", model.String("sampler.type", "const"), false))
+}
+
+func TestTagPolicy(t *testing.T) {
+	noProcessTags := false
+	p, err := NewTagPolicy(TagPolicyConfig{
+		TagRulesConfig: TagRulesConfig{
+			Deny:           []string{"request.id"},
+			DenyRegex:      []string{`.*\.uuid`},
+			MaxValueLength: 10,
+		},
+		ProcessTags: &noProcessTags,
+		Services: []ServiceTagRulesConfig{
+			{Service: "payments", TagRulesConfig: TagRulesConfig{
+				Allow:          []string{"payment.status"},
+				AllowRegex:     []string{`http\..*`},
+				Deny:           []string{"http.url"},
+				MaxValueLength: 20,
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		service string
+		kv      model.KeyValue
+		process bool
+		reason  string
+	}{
+		{"svc", model.String("http.method", "GET"), false, ""},
+		{"svc", model.String("request.id", "1"), false, skipDeny},
+		{"svc", model.String("user.uuid", "1"), false, skipDeny},
+		{"svc", model.String("hostname", "host"), true, skipProcess},
+		{"svc", model.String("error.message", strings.Repeat("a", 11)), false, skipValueLength},
+		{"svc", model.Int64("http.status_code", 12345678901), false, ""},
+		{"payments", model.String("payment.status", strings.Repeat("a", 11)), false, ""},
+		{"payments", model.String("http.method", "GET"), false, ""},
+		{"payments", model.String("http.url", "/"), false, skipDeny},
+		{"payments", model.String("request.id", "1"), false, skipDeny},
+		{"payments", model.String("db.statement", "select"), false, skipNotAllowed},
+	} {
+		assert.Equal(t, tc.reason, p.check(tc.service, tc.kv, tc.process), "%s %s", tc.service, tc.kv.Key)
+	}
+}
+
+func TestNewTagPolicyErrors(t *testing.T) {
+	_, err := NewTagPolicy(TagPolicyConfig{TagRulesConfig: TagRulesConfig{DenyRegex: []string{"("}}})
+	assert.Error(t, err)
+	_, err = NewTagPolicy(TagPolicyConfig{TagRulesConfig: TagRulesConfig{MaxValueLength: -1}})
+	assert.Error(t, err)
+	_, err = NewTagPolicy(TagPolicyConfig{Services: []ServiceTagRulesConfig{{}}})
+	assert.Error(t, err)
+	_, err = NewTagPolicy(TagPolicyConfig{Services: []ServiceTagRulesConfig{{Service: "a"}, {Service: "a"}}})
+	assert.Error(t, err)
+}
+
+func TestTagCounters(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	c := newTagCounters(mf)
+	c.inc("http.method", "")
+	c.inc("http.method", "")
+	c.inc("request.id", skipDeny)
+	for i := 0; i < maxCountedTagKeys; i++ {
+		c.inc("key"+strings.Repeat("x", i), "")
+	}
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "indexed", Tags: map[string]string{"key": "http.method"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "skipped", Tags: map[string]string{"key": "request.id", "reason": skipDeny}, Value: 1},
+		metricstest.ExpectedMetric{Name: "indexed", Tags: map[string]string{"key": otherTagKey}, Value: 2},
+	)
+}
+
+func TestWatchTagPolicyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("deny: [request.id]\n"), 0o600))
+	mf := metricstest.NewFactory(0)
+	s, err := WatchTagPolicyFile(path, time.Millisecond*10, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, skipDeny, s.Policy().check("svc", model.String("request.id", "1"), false))
+
+	// invalid policy is ignored
+	require.NoError(t, os.WriteFile(path, []byte("deny-regex: ['(']\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["reload_errors"] > 0
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, skipDeny, s.Policy().check("svc", model.String("request.id", "1"), false))
+
+	require.NoError(t, os.WriteFile(path, []byte("services:\n  - {service: svc, allow: [http.method]}\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		return s.Policy().check("svc", model.String("request.id", "1"), false) == skipNotAllowed
+	}, time.Second, time.Millisecond*10)
+
+	_, err = WatchTagPolicyFile(filepath.Join(t.TempDir(), "missing.yaml"), 0, mf, zap.NewNop())
+	assert.Error(t, err)
+}
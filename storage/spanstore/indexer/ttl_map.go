@@ -13,9 +13,11 @@ type indexTTLMap struct {
 	maxItemsPerKey int
 	maxTTL         time.Duration
 
-	evict indexMapEvictFunc
-	m     map[indexMapKey]*ttlMapValue
-	l     sync.Mutex
+	evict  indexMapEvictFunc
+	m      map[indexMapKey]*ttlMapValue
+	l      sync.Mutex
+	doneCh chan struct{}
+	wg     sync.WaitGroup
 }
 
 type indexMapKey struct {
@@ -43,21 +45,43 @@ func newIndexMap(evict indexMapEvictFunc, maxItemsPerKey int, maxTTL time.Durati
 		maxTTL:         maxTTL,
 		evict:          evict,
 		m:              make(map[indexMapKey]*ttlMapValue),
+		doneCh:         make(chan struct{}),
 	}
+	m.wg.Add(1)
 	go m.evictProcess()
 	return m
 }
 
 func (m *indexTTLMap) evictProcess() {
-	for now := range time.Tick(time.Second) {
-		m.l.Lock()
-		for k, v := range m.m {
-			if now.Sub(v.lastAccess) >= m.maxTTL {
-				delete(m.m, k)
-				m.evict(v.idx, v.traceIds)
+	defer m.wg.Done()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.doneCh:
+			return
+		case now := <-ticker.C:
+			m.l.Lock()
+			for k, v := range m.m {
+				if now.Sub(v.lastAccess) >= m.maxTTL {
+					delete(m.m, k)
+					m.evict(v.idx, v.traceIds)
+				}
 			}
+			m.l.Unlock()
 		}
-		m.l.Unlock()
+	}
+}
+
+// Close stops eviction and passes every remaining entry to evict
+func (m *indexTTLMap) Close(evict indexMapEvictFunc) {
+	close(m.doneCh)
+	m.wg.Wait()
+	m.l.Lock()
+	defer m.l.Unlock()
+	for k, v := range m.m {
+		delete(m.m, k)
+		evict(v.idx, v.traceIds)
 	}
 }
 
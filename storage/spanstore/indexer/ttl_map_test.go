@@ -0,0 +1,34 @@
+package indexer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/indexer/index"
+)
+
+func TestIndexTTLMap_Close(t *testing.T) {
+	var evicted []model.TraceID
+	m := newIndexMap(func(idx index.Indexable, traceIds []model.TraceID) {
+		evicted = append(evicted, traceIds...)
+	}, 10, time.Hour)
+
+	span := &model.Span{
+		TraceID:       model.NewTraceID(0, 1),
+		StartTime:     time.Now(),
+		OperationName: "op",
+		Process:       model.NewProcess("svc", nil),
+	}
+	m.Add(index.NewServiceNameIndex(span), span.TraceID)
+	m.Add(index.NewServiceNameIndex(span), model.NewTraceID(0, 2))
+
+	var flushed []model.TraceID
+	m.Close(func(idx index.Indexable, traceIds []model.TraceID) {
+		flushed = append(flushed, traceIds...)
+	})
+	assert.Empty(t, evicted)
+	assert.Equal(t, []model.TraceID{model.NewTraceID(0, 1), model.NewTraceID(0, 2)}, flushed)
+}
@@ -74,7 +74,28 @@ func (w *indexWriter) flush(idx index.Indexable, traceIds []model.TraceID) {
 	}
 }
 
+// Close flushes pending index entries, entries which can't be written before ctx is done are lost
+func (w *indexWriter) Close(ctx context.Context) batch.DrainStats {
+	var lost int64
+	w.indexTTLMap.Close(func(idx index.Indexable, traceIds []model.TraceID) {
+		if err := w.batch.AddWait(ctx, indexData{idx: idx, traceIds: traceIds}); err != nil {
+			lost++
+		}
+	})
+	stats := w.batch.Close(ctx)
+	stats.Lost += lost
+	return stats
+}
+
 func (w *indexWriter) WriteItems(items []interface{}) {
+	if w.opts.Archive {
+		data := make([]indexData, 0, len(items))
+		for _, item := range items {
+			data = append(data, item.(indexData))
+		}
+		w.writePartition(schema.PartitionKey{}, data)
+		return
+	}
 	parts := map[schema.PartitionKey][]indexData{}
 	for _, item := range items {
 		data := item.(indexData)
@@ -86,8 +107,12 @@ func (w *indexWriter) WriteItems(items []interface{}) {
 	}
 }
 
+// writePartition writes index entries to partition table, archive table is written for empty partition key
 func (w *indexWriter) writePartition(part schema.PartitionKey, items []indexData) {
-	fullTableName := tableName(w.opts.DbPath, part, w.tableName)
+	fullTableName := w.opts.DbPath.FullTable(w.tableName)
+	if !w.opts.Archive {
+		fullTableName = tableName(w.opts.DbPath, part, w.tableName)
+	}
 	brr := newBucketRR(dbmodel.NumIndexBuckets)
 	rows := make([]types.Value, 0, len(items))
 	for _, item := range items {
@@ -110,6 +135,9 @@ func (w *indexWriter) writePartition(part schema.PartitionKey, items []indexData
 		defer cancel()
 	}
 	err := db.UpsertData(ctx, w.pool, fullTableName, types.ListValue(rows...), w.opts.RetryAttemptTimeout)
+	if !w.opts.Archive && db.IsPathNotExistError(err) && w.createPartition(ctx, part) {
+		err = db.UpsertData(ctx, w.pool, fullTableName, types.ListValue(rows...), w.opts.RetryAttemptTimeout)
+	}
 
 	w.metrics.Emit(err, time.Since(ts), len(rows))
 	if err != nil {
@@ -122,6 +150,18 @@ func (w *indexWriter) writePartition(part schema.PartitionKey, items []indexData
 	}
 }
 
+// createPartition creates partition which tables don't exist, partitions out of range aren't created
+func (w *indexWriter) createPartition(ctx context.Context, part schema.PartitionKey) bool {
+	if w.opts.Partitions == nil {
+		return false
+	}
+	err := w.opts.Partitions.CreateMissing(ctx, part)
+	if err != nil && err != schema.ErrPartitionOutOfRange {
+		w.logger.Error("create partition error", zap.String("suffix", part.Suffix()), zap.Error(err))
+	}
+	return err == nil
+}
+
 func tableName(dbPath schema.DbPath, part schema.PartitionKey, tableName string) string {
 	return part.BuildFullTableName(dbPath.String(), tableName)
 }
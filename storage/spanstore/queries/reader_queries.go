@@ -92,6 +92,18 @@ SELECT operation_name
 FROM ` + "`%s`" + `
 WHERE service_name = $service_name AND span_kind = $span_kind
 LIMIT $limit`
+
+	queryDependencies = `DECLARE $ts_min AS uint64;
+DECLARE $ts_max AS uint64;
+$rows = SELECT ts, parent, child, source, call_count
+FROM ` + "`%s`" + `
+WHERE ts >= $ts_min AND ts <= $ts_max;
+$job_buckets = SELECT DISTINCT ts FROM $rows WHERE source = "job";
+SELECT r.parent AS parent, r.child AS child, SUM(r.call_count) AS call_count
+FROM $rows AS r
+LEFT JOIN $job_buckets AS j ON r.ts = j.ts
+WHERE r.source = "job" OR j.ts IS NULL
+GROUP BY r.parent, r.child`
 )
 
 var (
@@ -99,8 +111,21 @@ var (
 		"query-services":             {"service_names", queryServiceNames},
 		"query-operations":           {"operation_names_v2", queryOperations},
 		"query-operations-with-kind": {"operation_names_v2", queryOperationsWithKind},
-		"queryByTraceID":             {"archive", queryByTraceID},
-		"querySpanCount":             {"archive", querySpanCount},
+		"query-dependencies":         {"dependencies", queryDependencies},
+	}
+
+	// am has queries of archive storage, its tables aren't partitioned
+	am = map[string]queryInfo{
+		"query-services":                 {"archive_service_names", queryServiceNames},
+		"query-operations":               {"archive_operation_names_v2", queryOperations},
+		"query-operations-with-kind":     {"archive_operation_names_v2", queryOperationsWithKind},
+		"queryByTraceID":                 {"archive", queryByTraceID},
+		"querySpanCount":                 {"archive", querySpanCount},
+		"queryByTag":                     {"archive_idx_tag_v2", queryByTag},
+		"queryByTagAndOperation":         {"archive_idx_tag_v2", queryByTagAndOperation},
+		"queryByDuration":                {"archive_idx_duration", queryByDuration},
+		"queryByServiceAndOperationName": {"archive_idx_service_op", queryByServiceAndOperationName},
+		"queryByServiceName":             {"archive_idx_service_name", queryByServiceName},
 	}
 
 	pm = map[string]queryInfo{
@@ -126,6 +151,13 @@ func BuildQuery(queryName string, path schema.DbPath) string {
 	panic("query not found")
 }
 
+func BuildArchiveQuery(queryName string, path schema.DbPath) string {
+	if i, ok := am[queryName]; ok {
+		return fmt.Sprintf(i.query, path.FullTable(i.table))
+	}
+	panic("query not found")
+}
+
 func BuildPartitionQuery(queryName string, path schema.DbPath, part schema.PartitionKey) string {
 	if i, ok := pm[queryName]; ok {
 		ft := new(strings.Builder)
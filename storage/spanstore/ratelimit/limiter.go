@@ -0,0 +1,216 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+const (
+	defaultBurst = time.Second
+	// maxServices bounds number of buckets kept, the least recently used service gets a full bucket when it comes back.
+	// The same number of services get their own metrics, the rest are counted as otherService.
+	maxServices  = 1000
+	otherService = "other"
+)
+
+// LimitConfig sets rates for a single service, zero rate means no limit.
+// Bytes are estimated size of stored spans.
+type LimitConfig struct {
+	Service        string  `mapstructure:"service"`
+	SpansPerSecond float64 `mapstructure:"spans-per-second"`
+	BytesPerSecond float64 `mapstructure:"bytes-per-second"`
+}
+
+func (c LimitConfig) unlimited() bool {
+	return c.SpansPerSecond <= 0 && c.BytesPerSecond <= 0
+}
+
+type Options struct {
+	// Default applies to every service without override
+	Default   LimitConfig
+	Overrides []LimitConfig
+	// Burst is the bucket capacity expressed as time of traffic at full rate
+	Burst time.Duration
+}
+
+// Limiter keeps token buckets per service, so one service can't use up capacity of the others
+type Limiter struct {
+	opts      Options
+	overrides map[string]LimitConfig
+	mf        metrics.Factory
+
+	// mu serializes creation of service limiters
+	mu       sync.Mutex
+	services *lru.Cache
+	counted  map[string]struct{}
+}
+
+// NewLimiter validates limits and creates limiter
+func NewLimiter(opts Options, mf metrics.Factory) (*Limiter, error) {
+	if opts.Burst <= 0 {
+		opts.Burst = defaultBurst
+	}
+	if err := validate(opts.Default); err != nil {
+		return nil, fmt.Errorf("default limit: %w", err)
+	}
+	overrides := make(map[string]LimitConfig, len(opts.Overrides))
+	for _, cfg := range opts.Overrides {
+		if cfg.Service == "" {
+			return nil, errors.New("limit override: service must be set")
+		}
+		if _, exists := overrides[cfg.Service]; exists {
+			return nil, fmt.Errorf("limit override '%s': duplicate service", cfg.Service)
+		}
+		if err := validate(cfg); err != nil {
+			return nil, fmt.Errorf("limit override '%s': %w", cfg.Service, err)
+		}
+		overrides[cfg.Service] = cfg
+	}
+	services, err := lru.New(maxServices)
+	if err != nil {
+		return nil, err
+	}
+	return &Limiter{
+		opts:      opts,
+		overrides: overrides,
+		mf:        mf,
+		services:  services,
+		counted:   make(map[string]struct{}),
+	}, nil
+}
+
+func validate(cfg LimitConfig) error {
+	if cfg.SpansPerSecond < 0 || cfg.BytesPerSecond < 0 {
+		return errors.New("rate can't be negative")
+	}
+	return nil
+}
+
+// Allow takes tokens for span from its service buckets, false means span should be dropped
+func (l *Limiter) Allow(span *model.Span) bool {
+	s := l.service(span.GetProcess().GetServiceName())
+	if s.cfg.unlimited() {
+		return true
+	}
+	size := 0
+	if s.cfg.BytesPerSecond > 0 {
+		size = dbmodel.EstimateSize(span)
+	}
+	if !s.take(time.Now(), size) {
+		s.dropped.Inc(1)
+		s.droppedBytes.Inc(int64(size))
+		return false
+	}
+	return true
+}
+
+func (l *Limiter) service(name string) *serviceLimiter {
+	if s, ok := l.services.Get(name); ok {
+		return s.(*serviceLimiter)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.services.Get(name); ok {
+		return s.(*serviceLimiter)
+	}
+	cfg, ok := l.overrides[name]
+	if !ok {
+		cfg = l.opts.Default
+	}
+	tags := map[string]string{"svc": l.metricService(name)}
+	now := time.Now()
+	s := &serviceLimiter{
+		cfg:          cfg,
+		spans:        newBucket(cfg.SpansPerSecond, l.opts.Burst, now),
+		bytes:        newBucket(cfg.BytesPerSecond, l.opts.Burst, now),
+		dropped:      l.mf.Counter(metrics.Options{Name: "dropped_spans", Tags: tags}),
+		droppedBytes: l.mf.Counter(metrics.Options{Name: "dropped_bytes", Tags: tags}),
+	}
+	l.services.Add(name, s)
+	return s
+}
+
+// metricService returns svc tag of service metrics, so unique service names can't grow number of metrics
+func (l *Limiter) metricService(name string) string {
+	if _, ok := l.counted[name]; ok {
+		return name
+	}
+	if len(l.counted) >= maxServices {
+		return otherService
+	}
+	l.counted[name] = struct{}{}
+	return name
+}
+
+type serviceLimiter struct {
+	cfg          LimitConfig
+	dropped      metrics.Counter
+	droppedBytes metrics.Counter
+
+	mu    sync.Mutex
+	spans bucket
+	bytes bucket
+}
+
+// take succeeds only if both buckets have enough tokens
+func (s *serviceLimiter) take(now time.Time, size int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spans.refill(now)
+	s.bytes.refill(now)
+	if !s.spans.has(1) || !s.bytes.has(float64(size)) {
+		return false
+	}
+	s.spans.take(1)
+	s.bytes.take(float64(size))
+	return true
+}
+
+// bucket with zero rate is unlimited
+type bucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newBucket(rate float64, burst time.Duration, now time.Time) bucket {
+	capacity := rate * burst.Seconds()
+	return bucket{rate: rate, capacity: capacity, tokens: capacity, last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.rate <= 0 || !now.After(b.last) {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+func (b *bucket) take(n float64) {
+	if b.rate > 0 {
+		b.tokens -= n
+	}
+}
+
+// has checks if n tokens can be taken, item larger than capacity passes when bucket is full and leaves it in debt
+func (b *bucket) has(n float64) bool {
+	if b.rate <= 0 {
+		return true
+	}
+	if n > b.capacity {
+		n = b.capacity
+	}
+	return b.tokens >= n
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestLimiter(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	l, err := NewLimiter(Options{
+		Default: LimitConfig{SpansPerSecond: 2},
+		Overrides: []LimitConfig{
+			{Service: "big", SpansPerSecond: 10},
+			{Service: "free"},
+		},
+	}, mf)
+	require.NoError(t, err)
+	newSpan := func(svc string) *model.Span {
+		return &model.Span{Process: model.NewProcess(svc, nil)}
+	}
+
+	passed := map[string]int{}
+	for i := 0; i < 20; i++ {
+		for _, svc := range []string{"small", "big", "free"} {
+			if l.Allow(newSpan(svc)) {
+				passed[svc]++
+			}
+		}
+	}
+	// test runs much faster than a token is refilled
+	assert.Equal(t, map[string]int{"small": 2, "big": 10, "free": 20}, passed)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "dropped_spans", Tags: map[string]string{"svc": "small"}, Value: 18},
+		metricstest.ExpectedMetric{Name: "dropped_spans", Tags: map[string]string{"svc": "big"}, Value: 10},
+	)
+}
+
+func TestLimiter_MaxServices(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	l, err := NewLimiter(Options{Default: LimitConfig{SpansPerSecond: 1}}, mf)
+	require.NoError(t, err)
+	for i := 0; i < maxServices+10; i++ {
+		svc := &model.Span{Process: model.NewProcess(fmt.Sprintf("svc-%d", i), nil)}
+		assert.True(t, l.Allow(svc))
+		assert.False(t, l.Allow(svc))
+	}
+	assert.Equal(t, maxServices, l.services.Len())
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "dropped_spans", Tags: map[string]string{"svc": "svc-0"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "dropped_spans", Tags: map[string]string{"svc": otherService}, Value: 10},
+	)
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(100, time.Second, now)
+	assert.True(t, b.has(100))
+	b.take(100)
+	assert.False(t, b.has(1))
+	b.refill(now.Add(time.Millisecond * 500))
+	assert.True(t, b.has(50))
+	assert.False(t, b.has(51))
+	// item larger than capacity passes when bucket is full
+	b.refill(now.Add(time.Hour))
+	assert.True(t, b.has(1000))
+	b.take(1000)
+	b.refill(now.Add(time.Hour + time.Second))
+	assert.False(t, b.has(1))
+
+	unlimited := newBucket(0, time.Second, now)
+	unlimited.take(1000)
+	assert.True(t, unlimited.has(1000))
+}
+
+func TestNewLimiter_Validate(t *testing.T) {
+	_, err := NewLimiter(Options{Default: LimitConfig{SpansPerSecond: -1}}, metricstest.NewFactory(0))
+	assert.Error(t, err)
+	_, err = NewLimiter(Options{Overrides: []LimitConfig{{SpansPerSecond: 1}}}, metricstest.NewFactory(0))
+	assert.Error(t, err)
+	_, err = NewLimiter(Options{Overrides: []LimitConfig{{Service: "a"}, {Service: "a"}}}, metricstest.NewFactory(0))
+	assert.Error(t, err)
+}
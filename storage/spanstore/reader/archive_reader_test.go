@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/uber/jaeger-lib/metrics"
 
@@ -32,6 +33,77 @@ func TestArchiveSpanReader_GetTrace(t *testing.T) {
 	assert.Equal(t, model.SpanID(42), trace.Spans[0].SpanID)
 }
 
+func TestArchiveSpanReader_FindTraces(t *testing.T) {
+	addArchiveTestData(t)
+	s := setUpArchiveReader(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	t.Run("service_name", func(t *testing.T) {
+		traces, err := s.FindTraces(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:  "svc1",
+			StartTimeMin: time.Now().Add(-time.Hour),
+			StartTimeMax: time.Now().Add(time.Hour),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.Len(t, traces, 1) {
+			return
+		}
+		assert.Len(t, traces[0].Spans, 2)
+	})
+	t.Run("duration", func(t *testing.T) {
+		ids, err := s.FindTraceIDs(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:  "svc2",
+			StartTimeMin: time.Now().Add(-time.Hour),
+			StartTimeMax: time.Now().Add(time.Hour * 3),
+			DurationMin:  time.Second * 9,
+			DurationMax:  time.Second * 12,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.TraceID{model.NewTraceID(2, 42)}, ids)
+	})
+	t.Run("tags", func(t *testing.T) {
+		ids, err := s.FindTraceIDs(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:   "svc1",
+			OperationName: "this-stuff",
+			StartTimeMin:  time.Now().Add(-time.Hour),
+			StartTimeMax:  time.Now().Add(time.Hour),
+			Tags: map[string]string{
+				"some_tag": "some_value",
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.TraceID{model.NewTraceID(1, 42)}, ids)
+	})
+}
+
+func TestArchiveSpanReader_GetServices(t *testing.T) {
+	addArchiveTestData(t)
+	s := setUpArchiveReader(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	services, err := s.GetServices(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, services, "svc1")
+	assert.Contains(t, services, "svc2")
+}
+
+func TestArchiveSpanReader_GetOperations(t *testing.T) {
+	addArchiveTestData(t)
+	s := setUpArchiveReader(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ops, err := s.GetOperations(ctx, spanstore.OperationQueryParameters{ServiceName: "svc1"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.ElementsMatch(t, []spanstore.Operation{{Name: "this-stuff"}, {Name: "that-stuff"}}, ops)
+}
+
 var archiveOnce = new(sync.Once)
 
 func addArchiveTestData(t *testing.T) {
@@ -121,6 +193,8 @@ func setUpArchiveReader(t *testing.T) *SpanReader {
 			DbPath:        schema.DbPath{Path: os.Getenv("YDB_PATH"), Folder: os.Getenv("YDB_FOLDER")},
 			ReadTimeout:   time.Second * 10,
 			QueryParallel: 10,
+			OpLimit:       100,
+			SvcLimit:      100,
 			ArchiveReader: true,
 		},
 		testutil.Zap(),
@@ -87,6 +87,8 @@ type SpanReaderOptions struct {
 	SvcLimit      uint64 // max number of services to fetch
 	QueryParallel int
 	ArchiveReader bool
+	// Otlp attaches OTLP fields stored with spans by dbmodel.AttachOtlp, for readers converting spans back to OTLP
+	Otlp bool
 }
 
 // NewSpanReader returns a new SpanReader.
@@ -108,7 +110,7 @@ func (s *SpanReader) GetServices(ctx context.Context) ([]string, error) {
 	err := s.pool.Do(ctx, func(ctx context.Context, session table.Session) error {
 		res, err := session.StreamExecuteScanQuery(
 			ctx,
-			queries.BuildQuery("query-services", s.opts.DbPath),
+			s.buildQuery("query-services"),
 			table.NewQueryParameters(
 				table.ValueParam("$limit", types.Uint64Value(s.opts.SvcLimit)),
 			),
@@ -144,14 +146,14 @@ func (s *SpanReader) GetOperations(ctx context.Context, query spanstore.Operatio
 	var prepQuery string
 	var queryParameters *table.QueryParameters
 	if len(query.SpanKind) > 0 {
-		prepQuery = queries.BuildQuery("query-operations-with-kind", s.opts.DbPath)
+		prepQuery = s.buildQuery("query-operations-with-kind")
 		queryParameters = table.NewQueryParameters(
 			table.ValueParam("$service_name", types.TextValue(query.ServiceName)),
 			table.ValueParam("$span_kind", types.TextValue(query.SpanKind)),
 			table.ValueParam("$limit", types.Uint64Value(s.opts.OpLimit)),
 		)
 	} else {
-		prepQuery = queries.BuildQuery("query-operations", s.opts.DbPath)
+		prepQuery = s.buildQuery("query-operations")
 		queryParameters = table.NewQueryParameters(
 			table.ValueParam("$service_name", types.TextValue(query.ServiceName)),
 			table.ValueParam("$limit", types.Uint64Value(s.opts.OpLimit)),
@@ -198,14 +200,17 @@ func (s *SpanReader) FindTraces(ctx context.Context, query *spanstore.TraceQuery
 	}
 	var retMe []*model.Trace
 
-	parts := schema.MakePartitionList(query.StartTimeMin, query.StartTimeMax)
-	availableParts, err := s.getPartitionList(ctx)
-	if err != nil {
-		return nil, err
-	}
-	parts = schema.IntersectPartList(parts, availableParts)
-	if len(parts) == 0 {
-		return nil, ErrNoPartitions
+	var parts []schema.PartitionKey
+	if !s.opts.ArchiveReader {
+		parts = schema.MakePartitionList(query.StartTimeMin, query.StartTimeMax)
+		availableParts, err := s.getPartitionList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parts = schema.IntersectPartList(parts, availableParts)
+		if len(parts) == 0 {
+			return nil, ErrNoPartitions
+		}
 	}
 
 	queryC := make(chan model.TraceID)
@@ -218,7 +223,7 @@ func (s *SpanReader) FindTraces(ctx context.Context, query *spanstore.TraceQuery
 		go func() {
 			defer wg.Done()
 			for traceID := range queryC {
-				if jTrace, err := s.readTraceFromPartitions(ctx, parts, traceID); err != nil {
+				if jTrace, err := s.readFoundTrace(ctx, parts, traceID); err != nil {
 					s.logger.Error("Failure to read trace", zap.String("trace_id", traceID.String()), zap.Error(err))
 				} else {
 					mx.Lock()
@@ -301,6 +306,14 @@ func (s *SpanReader) readTrace(ctx context.Context, traceID model.TraceID) (*mod
 	return trace, err
 }
 
+// readFoundTrace reads trace found by index from given partitions or from archive table
+func (s *SpanReader) readFoundTrace(ctx context.Context, parts []schema.PartitionKey, traceID model.TraceID) (*model.Trace, error) {
+	if s.opts.ArchiveReader {
+		return s.readArchiveTrace(ctx, traceID)
+	}
+	return s.readTraceFromPartitions(ctx, parts, traceID)
+}
+
 func (s *SpanReader) queryPartitionList(ctx context.Context) ([]schema.PartitionKey, error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "queryPartitions")
 	defer span.Finish()
@@ -398,7 +411,7 @@ func (s *SpanReader) spansFromPartition(ctx context.Context, traceID model.Trace
 	var numSpans uint64
 	var query string
 	if s.opts.ArchiveReader {
-		query = queries.BuildQuery("querySpanCount", s.opts.DbPath)
+		query = queries.BuildArchiveQuery("querySpanCount", s.opts.DbPath)
 	} else {
 		query = queries.BuildPartitionQuery("querySpanCount", s.opts.DbPath, part)
 	}
@@ -433,7 +446,7 @@ func (s *SpanReader) spansFromPartition(ctx context.Context, traceID model.Trace
 	}
 
 	if s.opts.ArchiveReader {
-		query = queries.BuildQuery("queryByTraceID", s.opts.DbPath)
+		query = queries.BuildArchiveQuery("queryByTraceID", s.opts.DbPath)
 	} else {
 		query = queries.BuildPartitionQuery("queryByTraceID", s.opts.DbPath, part)
 	}
@@ -480,7 +493,7 @@ func (s *SpanReader) spansFromPartition(ctx context.Context, traceID model.Trace
 					if err != nil {
 						return fmt.Errorf("span.Scan failed: %w", err)
 					}
-					if span, err = dbmodel.ToDomain(&dbSpan); err != nil {
+					if span, err = s.decodeSpan(&dbSpan); err != nil {
 						return err
 					}
 					result = append(result, span)
@@ -500,6 +513,14 @@ func (s *SpanReader) spansFromPartition(ctx context.Context, traceID model.Trace
 	return result, err
 }
 
+func (s *SpanReader) decodeSpan(dbSpan *dbmodel.Span) (*model.Span, error) {
+	span, otlp, err := dbmodel.ToDomainOtlp(dbSpan)
+	if err != nil || otlp == nil || !s.opts.Otlp {
+		return span, err
+	}
+	return span, dbmodel.AttachOtlp(span, otlp)
+}
+
 func (s *SpanReader) findTraceIDs(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) (*dbmodel.UniqueTraceIDs, error) {
 	if traceQuery.DurationMin != 0 || traceQuery.DurationMax != 0 {
 		return s.queryByDuration(ctx, traceQuery)
@@ -615,6 +636,9 @@ func (s *SpanReader) queryByService(ctx context.Context, tq *spanstore.TraceQuer
 }
 
 func (s *SpanReader) queryParallel(ctx context.Context, parts []schema.PartitionKey, queryName string, tq *spanstore.TraceQueryParameters, values ...table.ParameterOption) ([]dbmodel.IndexResult, error) {
+	if s.opts.ArchiveReader {
+		return s.queryArchive(ctx, queryName, tq, values...)
+	}
 	availableParts, err := s.getPartitionList(ctx)
 	if err != nil {
 		return nil, err
@@ -655,6 +679,19 @@ func (s *SpanReader) queryInPartition(ctx context.Context, queryName string, par
 	return s.execQuery(ctx, span, query, values...)
 }
 
+// queryArchive runs index query against archive index tables, they cover all time range
+func (s *SpanReader) queryArchive(ctx context.Context, queryName string, tq *spanstore.TraceQueryParameters, values ...table.ParameterOption) ([]dbmodel.IndexResult, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "queryArchive")
+	defer span.Finish()
+
+	values = append(values,
+		table.ValueParam("$time_min", types.Int64Value(tq.StartTimeMin.UnixNano())),
+		table.ValueParam("$time_max", types.Int64Value(tq.StartTimeMax.UnixNano())),
+		table.ValueParam("$limit", types.Uint64Value(uint64(tq.NumTraces*limitMultiple))),
+	)
+	return s.execQuery(ctx, span, queries.BuildArchiveQuery(queryName, s.opts.DbPath), values...)
+}
+
 func (s *SpanReader) execQuery(ctx context.Context, span opentracing.Span, query string, values ...table.ParameterOption) ([]dbmodel.IndexResult, error) {
 	var result []dbmodel.IndexResult
 	err := s.pool.Do(ctx, func(ctx context.Context, session table.Session) error {
@@ -691,6 +728,13 @@ func (s *SpanReader) execQuery(ctx context.Context, span opentracing.Span, query
 	return result, nil
 }
 
+func (s *SpanReader) buildQuery(queryName string) string {
+	if s.opts.ArchiveReader {
+		return queries.BuildArchiveQuery(queryName, s.opts.DbPath)
+	}
+	return queries.BuildQuery(queryName, s.opts.DbPath)
+}
+
 func validateQuery(p *spanstore.TraceQueryParameters) error {
 	if p == nil {
 		return ErrMalformedRequestObject
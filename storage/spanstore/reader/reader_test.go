@@ -11,10 +11,12 @@ import (
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/ydb-platform/jaeger-ydb-store/internal/testutil"
 	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
 	ydbWriter "github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer"
 )
 
@@ -234,3 +236,34 @@ func setUpReader(t *testing.T) *SpanReader {
 		testutil.JaegerLogger(),
 	)
 }
+
+func TestSpanReader_DecodeSpanOtlp(t *testing.T) {
+	span := &model.Span{
+		TraceID:   model.NewTraceID(1, 2),
+		SpanID:    3,
+		StartTime: time.Now().Round(0).UTC(),
+		Process:   model.NewProcess("svc1", nil),
+		Tags:      []model.KeyValue{model.String("k", "v")},
+	}
+	otlp := &dbmodel.OtlpSpan{
+		Scope:  &dbmodel.InstrumentationScope{Name: "lib", Attributes: []model.KeyValue{model.String("a", "b")}},
+		Status: &dbmodel.Status{Code: dbmodel.Status_STATUS_CODE_ERROR, Message: "timeout"},
+		Links:  []dbmodel.Link{{TraceIdLow: 5, SpanId: 6, Attributes: []model.KeyValue{model.Int64("n", 1)}}},
+	}
+	require.NoError(t, dbmodel.AttachOtlp(span, otlp))
+	dbSpan, err := dbmodel.FromDomain(span)
+	require.NoError(t, err)
+
+	// Jaeger readers get spans without OTLP fields
+	s := &SpanReader{}
+	result, err := s.decodeSpan(dbSpan)
+	require.NoError(t, err)
+	assert.Nil(t, dbmodel.DetachOtlp(result))
+	assert.Equal(t, []model.KeyValue{model.String("k", "v")}, result.Tags)
+
+	s = &SpanReader{opts: SpanReaderOptions{Otlp: true}}
+	result, err = s.decodeSpan(dbSpan)
+	require.NoError(t, err)
+	assert.Equal(t, span, result)
+	assert.Equal(t, otlp, dbmodel.DetachOtlp(result))
+}
@@ -0,0 +1,205 @@
+package redaction
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+const (
+	ActionDrop = "drop"
+	ActionHash = "hash"
+	ActionMask = "mask"
+
+	defaultReplacement = "***"
+	hashPrefix         = "sha256:"
+)
+
+// RuleConfig describes single redaction rule.
+// Key is a regular expression matched against whole tag or log field key, empty Key matches any key.
+// Service limits rule to spans of a single service.
+// Pattern is a regular expression replaced with Replacement in string values, it's required for mask action.
+type RuleConfig struct {
+	Name        string `mapstructure:"name"`
+	Action      string `mapstructure:"action"`
+	Service     string `mapstructure:"service"`
+	Key         string `mapstructure:"key"`
+	Pattern     string `mapstructure:"pattern"`
+	Replacement string `mapstructure:"replacement"`
+}
+
+type Options struct {
+	Rules []RuleConfig
+	// HashSalt is prepended to values before hashing, so hashes of short values can't be looked up
+	HashSalt string
+}
+
+type rule struct {
+	name        string
+	action      string
+	service     string
+	key         *regexp.Regexp
+	pattern     *regexp.Regexp
+	replacement string
+	applied     metrics.Counter
+}
+
+func (r *rule) matchKey(key string) bool {
+	return r.key == nil || r.key.MatchString(key)
+}
+
+// Redactor applies rules to span tags, process tags and log fields in rules order
+type Redactor struct {
+	rules []*rule
+	salt  string
+}
+
+// NewRedactor compiles rules
+func NewRedactor(opts Options, mf metrics.Factory) (*Redactor, error) {
+	r := &Redactor{salt: opts.HashSalt}
+	for i, cfg := range opts.Rules {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("rule_%d", i)
+		}
+		rl := &rule{
+			name:        cfg.Name,
+			action:      cfg.Action,
+			service:     cfg.Service,
+			replacement: cfg.Replacement,
+			applied:     mf.Counter(metrics.Options{Name: "applied", Tags: map[string]string{"rule": cfg.Name}}),
+		}
+		if cfg.Key != "" {
+			re, err := regexp.Compile("^(?:" + cfg.Key + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("rule '%s': bad key: %w", cfg.Name, err)
+			}
+			rl.key = re
+		}
+		if cfg.Pattern != "" {
+			re, err := regexp.Compile(cfg.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule '%s': bad pattern: %w", cfg.Name, err)
+			}
+			rl.pattern = re
+		}
+		switch cfg.Action {
+		case ActionDrop, ActionHash:
+			if rl.key == nil {
+				return nil, fmt.Errorf("rule '%s': key is required for %s action", cfg.Name, cfg.Action)
+			}
+		case ActionMask:
+			if rl.pattern == nil {
+				return nil, fmt.Errorf("rule '%s': pattern is required for mask action", cfg.Name)
+			}
+			if rl.replacement == "" {
+				rl.replacement = defaultReplacement
+			}
+		default:
+			return nil, fmt.Errorf("rule '%s': unknown action '%s'", cfg.Name, cfg.Action)
+		}
+		r.rules = append(r.rules, rl)
+	}
+	return r, nil
+}
+
+// Apply redacts span. Span itself is modified, but tag slices and process are replaced with copies
+// when something is changed, because they may be shared with other spans.
+func (r *Redactor) Apply(span *model.Span) {
+	service := span.GetProcess().GetServiceName()
+	rules := r.serviceRules(service)
+	if len(rules) == 0 {
+		return
+	}
+	if tags, changed := r.redact(rules, span.Tags); changed {
+		span.Tags = tags
+	}
+	if span.Process != nil {
+		if tags, changed := r.redact(rules, span.Process.Tags); changed {
+			span.Process = model.NewProcess(span.Process.ServiceName, tags)
+		}
+	}
+	var logs []model.Log
+	for i, l := range span.Logs {
+		fields, changed := r.redact(rules, l.Fields)
+		if !changed {
+			continue
+		}
+		if logs == nil {
+			logs = append([]model.Log(nil), span.Logs...)
+		}
+		logs[i].Fields = fields
+	}
+	if logs != nil {
+		span.Logs = logs
+	}
+}
+
+func (r *Redactor) serviceRules(service string) []*rule {
+	rules := make([]*rule, 0, len(r.rules))
+	for _, rl := range r.rules {
+		if rl.service == "" || rl.service == service {
+			rules = append(rules, rl)
+		}
+	}
+	return rules
+}
+
+// redact returns new slice if any value was changed
+func (r *Redactor) redact(rules []*rule, kvs []model.KeyValue) ([]model.KeyValue, bool) {
+	var result []model.KeyValue
+	for i, kv := range kvs {
+		kv, keep, changed := r.redactValue(rules, kv)
+		if changed && result == nil {
+			result = make([]model.KeyValue, i, len(kvs))
+			copy(result, kvs[:i])
+		}
+		if result != nil && keep {
+			result = append(result, kv)
+		}
+	}
+	if result == nil {
+		return kvs, false
+	}
+	return result, true
+}
+
+func (r *Redactor) redactValue(rules []*rule, kv model.KeyValue) (model.KeyValue, bool, bool) {
+	changed := false
+	for _, rl := range rules {
+		if !rl.matchKey(kv.Key) {
+			continue
+		}
+		switch rl.action {
+		case ActionDrop:
+			rl.applied.Inc(1)
+			return kv, false, true
+		case ActionHash:
+			// hashed value isn't changed by other rules
+			rl.applied.Inc(1)
+			return model.String(kv.Key, r.hash(kv)), true, true
+		case ActionMask:
+			if kv.VType != model.StringType || !rl.pattern.MatchString(kv.VStr) {
+				continue
+			}
+			rl.applied.Inc(1)
+			kv = model.String(kv.Key, rl.pattern.ReplaceAllString(kv.VStr, rl.replacement))
+			changed = true
+		}
+	}
+	return kv, true, changed
+}
+
+func (r *Redactor) hash(kv model.KeyValue) string {
+	h := sha256.New()
+	h.Write([]byte(r.salt))
+	if kv.VType == model.BinaryType {
+		h.Write(kv.VBinary)
+	} else {
+		h.Write([]byte(kv.AsString()))
+	}
+	return hashPrefix + hex.EncodeToString(h.Sum(nil))
+}
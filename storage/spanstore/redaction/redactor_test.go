@@ -0,0 +1,86 @@
+package redaction
+
+import (
+	"testing"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestRedactor(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	r, err := NewRedactor(Options{
+		HashSalt: "salt",
+		Rules: []RuleConfig{
+			{Name: "password", Action: ActionDrop, Key: "(?i).*password.*"},
+			{Name: "user", Action: ActionHash, Key: "user\\.id", Service: "auth"},
+			{Name: "email", Action: ActionMask, Pattern: `[\w.]+@[\w.]+`},
+		},
+	}, mf)
+	require.NoError(t, err)
+
+	process := model.NewProcess("auth", []model.KeyValue{
+		model.String("hostname", "host1"),
+		model.String("db.Password", "secret"),
+	})
+	span := &model.Span{
+		Process: process,
+		Tags: []model.KeyValue{
+			model.Int64("user.id", 42),
+			model.String("http.url", "/users/john@example.com/profile"),
+			model.String("status", "ok"),
+		},
+		Logs: []model.Log{
+			{Fields: []model.KeyValue{model.String("event", "login")}},
+			{Fields: []model.KeyValue{model.String("password", "secret"), model.String("event", "retry")}},
+		},
+	}
+	logs := span.Logs
+	r.Apply(span)
+
+	userHash := span.Tags[0]
+	assert.Equal(t, "user.id", userHash.Key)
+	assert.Contains(t, userHash.VStr, hashPrefix)
+	assert.Equal(t, []model.KeyValue{
+		userHash,
+		model.String("http.url", "/users/***/profile"),
+		model.String("status", "ok"),
+	}, span.Tags)
+	assert.Equal(t, []model.KeyValue{model.String("hostname", "host1")}, span.Process.Tags)
+	assert.Equal(t, []model.KeyValue{model.String("event", "retry")}, span.Logs[1].Fields)
+	assert.Equal(t, []model.KeyValue{model.String("event", "login")}, span.Logs[0].Fields)
+
+	// shared data isn't modified
+	assert.Len(t, process.Tags, 2)
+	assert.Len(t, logs[1].Fields, 2)
+
+	// hash is stable
+	other := &model.Span{Process: model.NewProcess("auth", nil), Tags: []model.KeyValue{model.Int64("user.id", 42)}}
+	r.Apply(other)
+	assert.Equal(t, userHash, other.Tags[0])
+
+	// service rule doesn't match other services
+	other = &model.Span{Process: model.NewProcess("billing", nil), Tags: []model.KeyValue{model.Int64("user.id", 42)}}
+	r.Apply(other)
+	assert.Equal(t, model.Int64("user.id", 42), other.Tags[0])
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "applied", Tags: map[string]string{"rule": "password"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "applied", Tags: map[string]string{"rule": "user"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "applied", Tags: map[string]string{"rule": "email"}, Value: 1},
+	)
+}
+
+func TestNewRedactor_Invalid(t *testing.T) {
+	for _, cfg := range []RuleConfig{
+		{Action: ActionDrop},
+		{Action: ActionMask, Key: "k"},
+		{Action: ActionHash, Key: "("},
+		{Action: "unknown", Key: "k"},
+	} {
+		_, err := NewRedactor(Options{Rules: []RuleConfig{cfg}}, metricstest.NewFactory(0))
+		assert.Error(t, err, cfg)
+	}
+}
@@ -0,0 +1,220 @@
+package sampling
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	PolicyDebug         = "debug"
+	PolicyError         = "error"
+	PolicyLatency       = "latency"
+	PolicyProbabilistic = "probabilistic"
+	PolicyTag           = "tag"
+)
+
+// PolicyConfig describes single sampling policy, trace is kept if any policy matches it.
+// Service and Operation narrow down spans checked by error, latency and tag policies.
+type PolicyConfig struct {
+	Name      string        `mapstructure:"name"`
+	Type      string        `mapstructure:"type"`
+	Service   string        `mapstructure:"service"`
+	Operation string        `mapstructure:"operation"`
+	Threshold time.Duration `mapstructure:"threshold"`
+	Rate      float64       `mapstructure:"rate"`
+	Key       string        `mapstructure:"key"`
+	Value     string        `mapstructure:"value"`
+}
+
+type Policy interface {
+	Name() string
+	Match(trace []*model.Span) bool
+}
+
+// NewPolicy validates config and creates policy
+func NewPolicy(cfg PolicyConfig) (Policy, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+	filter := spanFilter{service: cfg.Service, operation: cfg.Operation}
+	switch cfg.Type {
+	case PolicyDebug:
+		return &debugPolicy{name: cfg.Name, filter: filter}, nil
+	case PolicyError:
+		return &errorPolicy{name: cfg.Name, filter: filter}, nil
+	case PolicyLatency:
+		if cfg.Threshold <= 0 {
+			return nil, fmt.Errorf("policy '%s': threshold must be positive", cfg.Name)
+		}
+		return &latencyPolicy{name: cfg.Name, filter: filter, threshold: cfg.Threshold}, nil
+	case PolicyProbabilistic:
+		if cfg.Rate < 0 || cfg.Rate > 1 {
+			return nil, fmt.Errorf("policy '%s': rate must be within [0, 1]", cfg.Name)
+		}
+		// float64(math.MaxUint64) is 2^64, which overflows uint64, so rate 1 is checked separately
+		if cfg.Rate >= 1 {
+			return &probabilisticPolicy{name: cfg.Name, all: true}, nil
+		}
+		return &probabilisticPolicy{name: cfg.Name, boundary: uint64(cfg.Rate * math.MaxUint64)}, nil
+	case PolicyTag:
+		if cfg.Key == "" {
+			return nil, fmt.Errorf("policy '%s': key must be set", cfg.Name)
+		}
+		return &tagPolicy{name: cfg.Name, filter: filter, key: cfg.Key, value: cfg.Value}, nil
+	default:
+		return nil, fmt.Errorf("policy '%s': unknown type '%s'", cfg.Name, cfg.Type)
+	}
+}
+
+type spanFilter struct {
+	service   string
+	operation string
+}
+
+func (f spanFilter) match(span *model.Span) bool {
+	if f.service != "" && span.GetProcess().GetServiceName() != f.service {
+		return false
+	}
+	if f.operation != "" && span.OperationName != f.operation {
+		return false
+	}
+	return true
+}
+
+// debugPolicy matches spans with debug flag, which clients set when sampling is forced, e.g. by jaeger-debug-id
+type debugPolicy struct {
+	name   string
+	filter spanFilter
+}
+
+func (p *debugPolicy) Name() string {
+	return p.name
+}
+
+func (p *debugPolicy) Match(trace []*model.Span) bool {
+	for _, span := range trace {
+		if p.filter.match(span) && span.Flags.IsDebug() {
+			return true
+		}
+	}
+	return false
+}
+
+type errorPolicy struct {
+	name   string
+	filter spanFilter
+}
+
+func (p *errorPolicy) Name() string {
+	return p.name
+}
+
+func (p *errorPolicy) Match(trace []*model.Span) bool {
+	for _, span := range trace {
+		if !p.filter.match(span) {
+			continue
+		}
+		if kv, ok := model.KeyValues(span.Tags).FindByKey("error"); ok && isTrue(kv) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(kv model.KeyValue) bool {
+	switch kv.VType {
+	case model.BoolType:
+		return kv.Bool()
+	case model.StringType:
+		v, _ := strconv.ParseBool(kv.VStr)
+		return v
+	}
+	return false
+}
+
+type latencyPolicy struct {
+	name      string
+	filter    spanFilter
+	threshold time.Duration
+}
+
+func (p *latencyPolicy) Name() string {
+	return p.name
+}
+
+func (p *latencyPolicy) Match(trace []*model.Span) bool {
+	for _, span := range trace {
+		if p.filter.match(span) && span.Duration >= p.threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// probabilisticPolicy decision depends on trace id only, so all collectors agree on it
+type probabilisticPolicy struct {
+	name     string
+	all      bool
+	boundary uint64
+}
+
+func (p *probabilisticPolicy) Name() string {
+	return p.name
+}
+
+func (p *probabilisticPolicy) Match(trace []*model.Span) bool {
+	if len(trace) == 0 {
+		return false
+	}
+	if p.all {
+		return true
+	}
+	// trace id low part is random for all supported clients, mix it anyway in case it's sequential
+	return mix(trace[0].TraceID.Low^trace[0].TraceID.High) < p.boundary
+}
+
+// mix is splitmix64 finalizer
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+type tagPolicy struct {
+	name   string
+	filter spanFilter
+	key    string
+	value  string
+}
+
+func (p *tagPolicy) Name() string {
+	return p.name
+}
+
+func (p *tagPolicy) Match(trace []*model.Span) bool {
+	for _, span := range trace {
+		if !p.filter.match(span) {
+			continue
+		}
+		if p.matchTags(span.Tags) || p.matchTags(span.GetProcess().GetTags()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *tagPolicy) matchTags(tags []model.KeyValue) bool {
+	for _, kv := range tags {
+		if kv.Key == p.key && (p.value == "" || kv.AsString() == p.value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package sampling
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+const (
+	defaultDecisionWait      = 10 * time.Second
+	defaultMaxTraces         = 50000
+	defaultDecisionCacheSize = 100000
+)
+
+type Options struct {
+	// DecisionWait is the time spans of a trace are buffered before decision is made
+	DecisionWait time.Duration
+	// MaxTraces limits number of buffered traces, the oldest trace is decided early when it's reached
+	MaxTraces int
+	// DecisionCacheSize is the number of decisions remembered for spans arriving late
+	DecisionCacheSize int
+	Policies          []PolicyConfig
+}
+
+// KeepFunc receives spans of kept traces
+type KeepFunc func(spans []*model.Span)
+
+type samplerMetrics struct {
+	tracesKept    metrics.Counter
+	tracesDropped metrics.Counter
+	spansKept     metrics.Counter
+	spansDropped  metrics.Counter
+	lateSpans     metrics.Counter
+	earlyDecided  metrics.Counter
+	buffered      metrics.Gauge
+}
+
+type pendingTrace struct {
+	traceID  model.TraceID
+	deadline time.Time
+}
+
+// Sampler buffers spans per trace and keeps or drops whole traces after decision wait
+type Sampler struct {
+	opts          Options
+	policies      []Policy
+	policyMatches []metrics.Counter
+	keep          KeepFunc
+	metrics       samplerMetrics
+
+	mu        sync.Mutex
+	traces    map[model.TraceID][]*model.Span
+	pending   []pendingTrace
+	decisions *lru.Cache
+
+	doneCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewSampler validates policies and creates sampler, it does nothing until Start is called
+func NewSampler(opts Options, mf metrics.Factory) (*Sampler, error) {
+	if opts.DecisionWait <= 0 {
+		opts.DecisionWait = defaultDecisionWait
+	}
+	if opts.MaxTraces <= 0 {
+		opts.MaxTraces = defaultMaxTraces
+	}
+	if opts.DecisionCacheSize <= 0 {
+		opts.DecisionCacheSize = defaultDecisionCacheSize
+	}
+	if len(opts.Policies) == 0 {
+		return nil, errors.New("at least one sampling policy is required")
+	}
+	decisions, err := lru.New(opts.DecisionCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sampler{
+		opts: opts,
+		metrics: samplerMetrics{
+			tracesKept:    mf.Counter(metrics.Options{Name: "traces_kept"}),
+			tracesDropped: mf.Counter(metrics.Options{Name: "traces_dropped"}),
+			spansKept:     mf.Counter(metrics.Options{Name: "spans_kept"}),
+			spansDropped:  mf.Counter(metrics.Options{Name: "spans_dropped"}),
+			lateSpans:     mf.Counter(metrics.Options{Name: "late_spans"}),
+			earlyDecided:  mf.Counter(metrics.Options{Name: "early_decisions"}),
+			buffered:      mf.Gauge(metrics.Options{Name: "buffered_traces"}),
+		},
+		traces:    make(map[model.TraceID][]*model.Span),
+		decisions: decisions,
+		doneCh:    make(chan struct{}),
+	}
+	for _, cfg := range opts.Policies {
+		p, err := NewPolicy(cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.policies = append(s.policies, p)
+		s.policyMatches = append(s.policyMatches,
+			mf.Counter(metrics.Options{Name: "policy_matches", Tags: map[string]string{"policy": p.Name()}}))
+	}
+	return s, nil
+}
+
+// Start runs decision process, spans of kept traces are passed to keep
+func (s *Sampler) Start(keep KeepFunc) {
+	s.keep = keep
+	s.wg.Add(1)
+	go s.decisionProcess()
+}
+
+// Add buffers span until its trace is decided, spans of already decided traces follow the decision
+func (s *Sampler) Add(span *model.Span) {
+	s.mu.Lock()
+	if keep, ok := s.decisions.Get(span.TraceID); ok {
+		s.mu.Unlock()
+		s.metrics.lateSpans.Inc(1)
+		s.release(keep.(bool), []*model.Span{span})
+		return
+	}
+	spans, exists := s.traces[span.TraceID]
+	s.traces[span.TraceID] = append(spans, span)
+	if exists {
+		s.mu.Unlock()
+		return
+	}
+	s.pending = append(s.pending, pendingTrace{traceID: span.TraceID, deadline: time.Now().Add(s.opts.DecisionWait)})
+	var decided []decision
+	if len(s.traces) > s.opts.MaxTraces {
+		s.metrics.earlyDecided.Inc(1)
+		decided = append(decided, s.decideLocked(s.pending[0].traceID))
+		s.pending = s.pending[1:]
+	}
+	s.metrics.buffered.Update(int64(len(s.traces)))
+	s.mu.Unlock()
+
+	for _, d := range decided {
+		s.release(d.keep, d.spans)
+	}
+}
+
+type decision struct {
+	keep  bool
+	spans []*model.Span
+}
+
+func (s *Sampler) decideLocked(traceID model.TraceID) decision {
+	spans := s.traces[traceID]
+	delete(s.traces, traceID)
+	keep := false
+	for i, p := range s.policies {
+		if p.Match(spans) {
+			s.policyMatches[i].Inc(1)
+			keep = true
+			break
+		}
+	}
+	s.decisions.Add(traceID, keep)
+	if keep {
+		s.metrics.tracesKept.Inc(1)
+	} else {
+		s.metrics.tracesDropped.Inc(1)
+	}
+	return decision{keep: keep, spans: spans}
+}
+
+func (s *Sampler) release(keep bool, spans []*model.Span) {
+	if !keep {
+		s.metrics.spansDropped.Inc(int64(len(spans)))
+		return
+	}
+	s.metrics.spansKept.Inc(int64(len(spans)))
+	s.keep(spans)
+}
+
+// decideExpired decides traces which waited long enough, all of them if now is zero
+func (s *Sampler) decideExpired(now time.Time) {
+	s.mu.Lock()
+	var decided []decision
+	n := 0
+	for _, p := range s.pending {
+		if !now.IsZero() && p.deadline.After(now) {
+			break
+		}
+		decided = append(decided, s.decideLocked(p.traceID))
+		n++
+	}
+	s.pending = s.pending[n:]
+	s.metrics.buffered.Update(int64(len(s.traces)))
+	s.mu.Unlock()
+
+	for _, d := range decided {
+		s.release(d.keep, d.spans)
+	}
+}
+
+func (s *Sampler) decisionProcess() {
+	defer s.wg.Done()
+	tick := time.Second
+	if s.opts.DecisionWait < tick {
+		tick = s.opts.DecisionWait
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.doneCh:
+			return
+		case now := <-ticker.C:
+			s.decideExpired(now)
+		}
+	}
+}
+
+// Close decides all buffered traces immediately
+func (s *Sampler) Close() {
+	close(s.doneCh)
+	s.wg.Wait()
+	s.decideExpired(time.Time{})
+}
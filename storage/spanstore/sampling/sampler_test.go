@@ -0,0 +1,174 @@
+package sampling
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func newSpan(traceID uint64, svc, op string, duration time.Duration, tags ...model.KeyValue) *model.Span {
+	return &model.Span{
+		TraceID:       model.NewTraceID(0, traceID),
+		SpanID:        model.NewSpanID(uint64(len(tags)) + 1),
+		OperationName: op,
+		Duration:      duration,
+		Tags:          tags,
+		Process:       model.NewProcess(svc, nil),
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		cfg   PolicyConfig
+		trace []*model.Span
+		match bool
+	}{
+		{
+			name:  "error bool",
+			cfg:   PolicyConfig{Type: PolicyError},
+			trace: []*model.Span{newSpan(1, "a", "op", 0), newSpan(1, "b", "op", 0, model.Bool("error", true))},
+			match: true,
+		},
+		{
+			name:  "error string",
+			cfg:   PolicyConfig{Type: PolicyError},
+			trace: []*model.Span{newSpan(1, "a", "op", 0, model.String("error", "true"))},
+			match: true,
+		},
+		{
+			name:  "error other service",
+			cfg:   PolicyConfig{Type: PolicyError, Service: "a"},
+			trace: []*model.Span{newSpan(1, "b", "op", 0, model.Bool("error", true))},
+		},
+		{
+			name:  "latency",
+			cfg:   PolicyConfig{Type: PolicyLatency, Service: "a", Operation: "op", Threshold: time.Second},
+			trace: []*model.Span{newSpan(1, "a", "op", 2*time.Second)},
+			match: true,
+		},
+		{
+			name:  "latency below threshold",
+			cfg:   PolicyConfig{Type: PolicyLatency, Threshold: time.Second},
+			trace: []*model.Span{newSpan(1, "a", "op", time.Millisecond)},
+		},
+		{
+			name:  "tag",
+			cfg:   PolicyConfig{Type: PolicyTag, Key: "user", Value: "42"},
+			trace: []*model.Span{newSpan(1, "a", "op", 0, model.Int64("user", 42))},
+			match: true,
+		},
+		{
+			name:  "tag value mismatch",
+			cfg:   PolicyConfig{Type: PolicyTag, Key: "user", Value: "42"},
+			trace: []*model.Span{newSpan(1, "a", "op", 0, model.Int64("user", 1))},
+		},
+		{
+			name:  "debug",
+			cfg:   PolicyConfig{Type: PolicyDebug},
+			trace: []*model.Span{newSpan(1, "a", "op", 0), {Flags: model.DebugFlag, Process: model.NewProcess("b", nil)}},
+			match: true,
+		},
+		{
+			name:  "debug not set",
+			cfg:   PolicyConfig{Type: PolicyDebug},
+			trace: []*model.Span{{Flags: model.SampledFlag, Process: model.NewProcess("b", nil)}},
+		},
+		{
+			name:  "probabilistic all",
+			cfg:   PolicyConfig{Type: PolicyProbabilistic, Rate: 1},
+			trace: []*model.Span{newSpan(1, "a", "op", 0)},
+			match: true,
+		},
+		{
+			name:  "probabilistic none",
+			cfg:   PolicyConfig{Type: PolicyProbabilistic, Rate: 0},
+			trace: []*model.Span{newSpan(1, "a", "op", 0)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewPolicy(tc.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tc.match, p.Match(tc.trace))
+		})
+	}
+
+	_, err := NewPolicy(PolicyConfig{Type: PolicyLatency})
+	assert.Error(t, err)
+	_, err = NewPolicy(PolicyConfig{Type: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestProbabilisticPolicy_Rate(t *testing.T) {
+	for _, tc := range []struct {
+		rate  float64
+		kept  int
+		delta float64
+	}{
+		{rate: 0, kept: 0},
+		{rate: 0.25, kept: 2500, delta: 300},
+		{rate: 1, kept: 10000},
+	} {
+		p, err := NewPolicy(PolicyConfig{Type: PolicyProbabilistic, Rate: tc.rate})
+		require.NoError(t, err)
+		kept := 0
+		for i := uint64(0); i < 10000; i++ {
+			if p.Match([]*model.Span{newSpan(i, "a", "op", 0)}) {
+				kept++
+			}
+		}
+		assert.InDelta(t, tc.kept, kept, tc.delta, "rate %v", tc.rate)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	s, err := NewSampler(Options{
+		DecisionWait: time.Hour,
+		MaxTraces:    2,
+		Policies:     []PolicyConfig{{Name: "errors", Type: PolicyError}},
+	}, mf)
+	require.NoError(t, err)
+
+	var (
+		mu   sync.Mutex
+		kept []*model.Span
+	)
+	s.Start(func(spans []*model.Span) {
+		mu.Lock()
+		defer mu.Unlock()
+		kept = append(kept, spans...)
+	})
+
+	s.Add(newSpan(1, "a", "op", 0))
+	s.Add(newSpan(1, "b", "op", 0, model.Bool("error", true)))
+	s.Add(newSpan(2, "a", "op", 0))
+	// third trace forces decision of the first one
+	s.Add(newSpan(3, "a", "op", 0))
+	assert.Len(t, kept, 2)
+	// late span follows decision
+	s.Add(newSpan(1, "c", "op", 0))
+	assert.Len(t, kept, 3)
+
+	s.Close()
+	assert.Len(t, kept, 3)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "traces_kept", Value: 1},
+		metricstest.ExpectedMetric{Name: "traces_dropped", Value: 2},
+		metricstest.ExpectedMetric{Name: "spans_kept", Value: 3},
+		metricstest.ExpectedMetric{Name: "spans_dropped", Value: 2},
+		metricstest.ExpectedMetric{Name: "late_spans", Value: 1},
+		metricstest.ExpectedMetric{Name: "early_decisions", Value: 1},
+		metricstest.ExpectedMetric{Name: "policy_matches", Tags: map[string]string{"policy": "errors"}, Value: 1},
+	)
+}
+
+func TestSampler_NoPolicies(t *testing.T) {
+	_, err := NewSampler(Options{}, metricstest.NewFactory(0))
+	assert.Error(t, err)
+}
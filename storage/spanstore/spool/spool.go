@@ -0,0 +1,483 @@
+package spool
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	segmentExt = ".seg"
+	// record header: payload length, payload crc32, write timestamp
+	headerSize = 4 + 4 + 8
+
+	defaultSegmentSize      = 64 << 20
+	defaultSyncInterval     = time.Second
+	defaultRetryInterval    = time.Second
+	defaultMaxRetryInterval = time.Minute
+)
+
+var (
+	ErrFull   = errors.New("spool is full")
+	ErrClosed = errors.New("spool is closed")
+
+	errCorrupted = errors.New("corrupted record")
+)
+
+type SyncPolicy string
+
+const (
+	// SyncAlways calls fsync after every record
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval calls fsync periodically
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to OS
+	SyncNever SyncPolicy = "never"
+)
+
+type Options struct {
+	Dir              string
+	MaxSize          int64
+	SegmentSize      int64
+	Sync             SyncPolicy
+	SyncInterval     time.Duration
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+}
+
+// ReplayFunc writes spooled items back to storage. Record is retried until ReplayFunc succeeds.
+type ReplayFunc func(items [][]byte) error
+
+type spoolMetrics struct {
+	bytes        metrics.Gauge
+	segments     metrics.Gauge
+	replayLag    metrics.Gauge
+	written      metrics.Counter
+	replayed     metrics.Counter
+	dropped      metrics.Counter
+	corrupted    metrics.Counter
+	replayErrors metrics.Counter
+}
+
+type segment struct {
+	seq  uint64
+	size int64
+}
+
+// Spool is a write-ahead directory of append-only segment files.
+// Records are replayed in the order they were written. Replay position isn't persisted,
+// so after restart the oldest segment is replayed from its beginning again, writers are expected to be idempotent.
+type Spool struct {
+	opts    Options
+	logger  *zap.Logger
+	metrics spoolMetrics
+
+	mu       sync.Mutex
+	segments []*segment
+	active   *os.File
+	size     int64
+	dirty    bool
+	closed   bool
+
+	notify chan struct{}
+	doneCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New opens spool directory, existing segments are kept for replay
+func New(opts Options, mf metrics.Factory, logger *zap.Logger) (*Spool, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = defaultSegmentSize
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = defaultSyncInterval
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultRetryInterval
+	}
+	if opts.MaxRetryInterval < opts.RetryInterval {
+		opts.MaxRetryInterval = defaultMaxRetryInterval
+	}
+	switch opts.Sync {
+	case "":
+		opts.Sync = SyncInterval
+	case SyncAlways, SyncInterval, SyncNever:
+	default:
+		return nil, fmt.Errorf("unknown spool sync policy '%s'", opts.Sync)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("spool dir: %w", err)
+	}
+
+	s := &Spool{
+		opts:   opts,
+		logger: logger,
+		metrics: spoolMetrics{
+			bytes:        mf.Gauge(metrics.Options{Name: "bytes"}),
+			segments:     mf.Gauge(metrics.Options{Name: "segments"}),
+			replayLag:    mf.Gauge(metrics.Options{Name: "replay_lag_ms"}),
+			written:      mf.Counter(metrics.Options{Name: "written"}),
+			replayed:     mf.Counter(metrics.Options{Name: "replayed"}),
+			dropped:      mf.Counter(metrics.Options{Name: "dropped"}),
+			corrupted:    mf.Counter(metrics.Options{Name: "corrupted"}),
+			replayErrors: mf.Counter(metrics.Options{Name: "replay_errors"}),
+		},
+		notify: make(chan struct{}, 1),
+		doneCh: make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	// never append to segments left from previous run, their tail may be torn
+	if err := s.rotate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("spool dir: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return fmt.Errorf("spool segment: %w", err)
+		}
+		if info.Size() == 0 {
+			_ = os.Remove(filepath.Join(s.opts.Dir, name))
+			continue
+		}
+		s.segments = append(s.segments, &segment{seq: seq, size: info.Size()})
+		s.size += info.Size()
+	}
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+	s.updateGauges()
+	return nil
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// rotate closes active segment and opens a new one, must be called with s.mu held or before start
+func (s *Spool) rotate() error {
+	if s.active != nil {
+		if s.opts.Sync != SyncNever {
+			_ = s.active.Sync()
+		}
+		_ = s.active.Close()
+		s.active = nil
+	}
+	var seq uint64
+	if n := len(s.segments); n > 0 {
+		seq = s.segments[n-1].seq + 1
+	}
+	f, err := os.OpenFile(s.path(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("spool segment: %w", err)
+	}
+	s.active = f
+	s.dirty = false
+	s.segments = append(s.segments, &segment{seq: seq})
+	s.updateGauges()
+	return nil
+}
+
+func (s *Spool) updateGauges() {
+	s.metrics.bytes.Update(s.size)
+	s.metrics.segments.Update(int64(len(s.segments)))
+}
+
+// Start runs replay and sync processes
+func (s *Spool) Start(replay ReplayFunc) {
+	s.wg.Add(1)
+	go s.replayProcess(replay)
+	if s.opts.Sync == SyncInterval {
+		s.wg.Add(1)
+		go s.syncProcess()
+	}
+}
+
+// Write appends items as a single record
+func (s *Spool) Write(items [][]byte) error {
+	buf := encodeRecord(items, time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if s.opts.MaxSize > 0 && s.size+int64(len(buf)) > s.opts.MaxSize {
+		s.metrics.dropped.Inc(int64(len(items)))
+		return ErrFull
+	}
+	active := s.segments[len(s.segments)-1]
+	if active.size > 0 && active.size+int64(len(buf)) > s.opts.SegmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		active = s.segments[len(s.segments)-1]
+	}
+	n, err := s.active.Write(buf)
+	active.size += int64(n)
+	s.size += int64(n)
+	if err != nil {
+		// don't leave torn record in active segment
+		_ = s.rotate()
+		return fmt.Errorf("spool write: %w", err)
+	}
+	if s.opts.Sync == SyncAlways {
+		if err = s.active.Sync(); err != nil {
+			return fmt.Errorf("spool sync: %w", err)
+		}
+	} else {
+		s.dirty = true
+	}
+	s.metrics.written.Inc(int64(len(items)))
+	s.updateGauges()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *Spool) syncProcess() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.doneCh:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty && s.active != nil {
+				if err := s.active.Sync(); err != nil {
+					s.logger.Error("spool sync failed", zap.Error(err))
+				}
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// next returns oldest segment and number of bytes available for reading.
+// Fully replayed active segment is rotated so it can be removed.
+func (s *Spool) next(seq uint64, offset int64) (*segment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seg := s.segments[0]
+	isActive := len(s.segments) == 1
+	if seg.seq == seq && offset >= seg.size {
+		if isActive {
+			if seg.size == 0 {
+				return nil, false
+			}
+			if err := s.rotate(); err != nil {
+				s.logger.Error("spool rotate failed", zap.Error(err))
+				return nil, false
+			}
+		}
+		s.remove(seg)
+		seg = s.segments[0]
+		if len(s.segments) == 1 && seg.size == 0 {
+			return nil, false
+		}
+	}
+	return &segment{seq: seg.seq, size: seg.size}, true
+}
+
+func (s *Spool) remove(seg *segment) {
+	if err := os.Remove(s.path(seg.seq)); err != nil && !os.IsNotExist(err) {
+		s.logger.Error("spool segment remove failed", zap.Uint64("seq", seg.seq), zap.Error(err))
+	}
+	s.segments = s.segments[1:]
+	s.size -= seg.size
+	s.updateGauges()
+}
+
+func (s *Spool) replayProcess(replay ReplayFunc) {
+	defer s.wg.Done()
+	var (
+		seq    uint64
+		offset int64
+		f      *os.File
+	)
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+	backoff := s.opts.RetryInterval
+	for {
+		seg, ok := s.next(seq, offset)
+		if !ok {
+			s.metrics.replayLag.Update(0)
+			select {
+			case <-s.doneCh:
+				return
+			case <-s.notify:
+			}
+			continue
+		}
+		if f == nil || seg.seq != seq {
+			if f != nil {
+				_ = f.Close()
+			}
+			var err error
+			if f, err = os.Open(s.path(seg.seq)); err != nil {
+				s.logger.Error("spool segment open failed", zap.Uint64("seq", seg.seq), zap.Error(err))
+				f = nil
+				if !s.wait(backoff) {
+					return
+				}
+				continue
+			}
+			seq, offset = seg.seq, 0
+		}
+
+		items, ts, n, err := readRecord(f, offset, seg.size)
+		if err != nil {
+			// rest of segment is unreadable, skip it
+			s.metrics.corrupted.Inc(1)
+			s.logger.Error("spool segment corrupted", zap.Uint64("seq", seq), zap.Int64("offset", offset), zap.Error(err))
+			offset = seg.size
+			continue
+		}
+		s.metrics.replayLag.Update(time.Since(ts).Milliseconds())
+		if err = replay(items); err != nil {
+			s.metrics.replayErrors.Inc(1)
+			if !s.wait(backoff) {
+				return
+			}
+			backoff *= 2
+			if backoff > s.opts.MaxRetryInterval {
+				backoff = s.opts.MaxRetryInterval
+			}
+			continue
+		}
+		backoff = s.opts.RetryInterval
+		offset += n
+		s.metrics.replayed.Inc(int64(len(items)))
+	}
+}
+
+func (s *Spool) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-s.doneCh:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// Close stops replay and syncs active segment, unreplayed records stay on disk
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.doneCh)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opts.Sync != SyncNever {
+		_ = s.active.Sync()
+	}
+	return s.active.Close()
+}
+
+func encodeRecord(items [][]byte, ts time.Time) []byte {
+	payloadSize := 4
+	for _, item := range items {
+		payloadSize += 4 + len(item)
+	}
+	buf := make([]byte, headerSize+payloadSize)
+	payload := buf[headerSize:]
+	binary.BigEndian.PutUint32(payload, uint32(len(items)))
+	pos := 4
+	for _, item := range items {
+		binary.BigEndian.PutUint32(payload[pos:], uint32(len(item)))
+		pos += 4
+		pos += copy(payload[pos:], item)
+	}
+	binary.BigEndian.PutUint32(buf[0:], uint32(payloadSize))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(payload))
+	binary.BigEndian.PutUint64(buf[8:], uint64(ts.UnixNano()))
+	return buf
+}
+
+// readRecord reads record at offset, returns items, record timestamp and record length
+func readRecord(r io.ReaderAt, offset, limit int64) ([][]byte, time.Time, int64, error) {
+	if limit-offset < headerSize {
+		return nil, time.Time{}, 0, errCorrupted
+	}
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	payloadSize := int64(binary.BigEndian.Uint32(header[0:]))
+	checksum := binary.BigEndian.Uint32(header[4:])
+	ts := time.Unix(0, int64(binary.BigEndian.Uint64(header[8:])))
+	if payloadSize < 4 || offset+headerSize+payloadSize > limit {
+		return nil, time.Time{}, 0, errCorrupted
+	}
+	payload := make([]byte, payloadSize)
+	if _, err := r.ReadAt(payload, offset+headerSize); err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, time.Time{}, 0, errCorrupted
+	}
+
+	n := binary.BigEndian.Uint32(payload)
+	items := make([][]byte, 0, n)
+	pos := int64(4)
+	for i := uint32(0); i < n; i++ {
+		if pos+4 > payloadSize {
+			return nil, time.Time{}, 0, errCorrupted
+		}
+		l := int64(binary.BigEndian.Uint32(payload[pos:]))
+		pos += 4
+		if pos+l > payloadSize {
+			return nil, time.Time{}, 0, errCorrupted
+		}
+		items = append(items, payload[pos:pos+l])
+		pos += l
+	}
+	return items, ts, headerSize + payloadSize, nil
+}
@@ -0,0 +1,138 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/testutil"
+)
+
+type collector struct {
+	mu    sync.Mutex
+	items []string
+	fail  bool
+}
+
+func (c *collector) replay(items [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return errors.New("db is unavailable")
+	}
+	for _, item := range items {
+		c.items = append(c.items, string(item))
+	}
+	return nil
+}
+
+func (c *collector) get() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.items...)
+}
+
+func (c *collector) setFail(fail bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fail = fail
+}
+
+func testOptions(dir string) Options {
+	return Options{
+		Dir:              dir,
+		SegmentSize:      64,
+		Sync:             SyncNever,
+		RetryInterval:    time.Millisecond,
+		MaxRetryInterval: 10 * time.Millisecond,
+	}
+}
+
+func TestSpool_Replay(t *testing.T) {
+	dir := t.TempDir()
+	c := &collector{fail: true}
+	s, err := New(testOptions(dir), metrics.NullFactory, testutil.Zap())
+	require.NoError(t, err)
+	s.Start(c.replay)
+
+	expected := []string{"a", "b", "c", "d", "e", "f"}
+	for i := 0; i < len(expected); i += 2 {
+		require.NoError(t, s.Write([][]byte{[]byte(expected[i]), []byte(expected[i+1])}))
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, c.get())
+
+	c.setFail(false)
+	assert.Eventually(t, func() bool {
+		return len(c.get()) == len(expected)
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, expected, c.get())
+	require.NoError(t, s.Close())
+}
+
+func TestSpool_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(testOptions(dir), metrics.NullFactory, testutil.Zap())
+	require.NoError(t, err)
+	for _, item := range []string{"first", "second", "third"} {
+		require.NoError(t, s.Write([][]byte{[]byte(item)}))
+	}
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Write([][]byte{[]byte("late")}), ErrClosed)
+
+	c := &collector{}
+	s, err = New(testOptions(dir), metrics.NullFactory, testutil.Zap())
+	require.NoError(t, err)
+	s.Start(c.replay)
+	assert.Eventually(t, func() bool {
+		return len(c.get()) == 3
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"first", "second", "third"}, c.get())
+	require.NoError(t, s.Close())
+
+	// replayed segments are removed
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestSpool_Corrupted(t *testing.T) {
+	dir := t.TempDir()
+	opts := testOptions(dir)
+	opts.SegmentSize = 1 << 20
+	s, err := New(opts, metrics.NullFactory, testutil.Zap())
+	require.NoError(t, err)
+	require.NoError(t, s.Write([][]byte{[]byte("good")}))
+	require.NoError(t, s.Write([][]byte{[]byte("torn")}))
+	path := s.path(s.segments[0].seq)
+	size := s.segments[0].size
+	require.NoError(t, s.Close())
+	require.NoError(t, os.Truncate(path, size-2))
+
+	c := &collector{}
+	s, err = New(opts, metrics.NullFactory, testutil.Zap())
+	require.NoError(t, err)
+	s.Start(c.replay)
+	require.NoError(t, s.Write([][]byte{[]byte("next")}))
+	assert.Eventually(t, func() bool {
+		return len(c.get()) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"good", "next"}, c.get())
+	require.NoError(t, s.Close())
+}
+
+func TestSpool_Full(t *testing.T) {
+	opts := testOptions(t.TempDir())
+	opts.MaxSize = 50
+	s, err := New(opts, metrics.NullFactory, testutil.Zap())
+	require.NoError(t, err)
+	require.NoError(t, s.Write([][]byte{make([]byte, 20)}))
+	assert.ErrorIs(t, s.Write([][]byte{make([]byte, 20)}), ErrFull)
+	require.NoError(t, s.Close())
+}
@@ -2,6 +2,7 @@ package writer
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -26,18 +27,22 @@ type ArchiveSpanWriter struct {
 	logger       *zap.Logger
 	jaegerLogger hclog.Logger
 	opts         BatchWriterOptions
+	retry        *retryQueue
+	dropped      atomic.Int64
 }
 
 func NewArchiveWriter(pool table.Client, factory metrics.Factory, logger *zap.Logger, jaegerLogger hclog.Logger, opts BatchWriterOptions) *ArchiveSpanWriter {
 	ns := factory.Namespace(metrics.NSOptions{Name: "archive"})
 
-	return &ArchiveSpanWriter{
+	w := &ArchiveSpanWriter{
 		pool:         pool,
 		logger:       logger,
 		jaegerLogger: jaegerLogger,
 		opts:         opts,
 		metrics:      newBatchWriterMetrics(ns),
 	}
+	w.retry = newRetryQueue(opts.Retry, w.metrics.traces, logger, w.writeItems, w.giveUp)
+	return w
 }
 
 func (w *ArchiveSpanWriter) WriteItems(items []interface{}) {
@@ -46,10 +51,45 @@ func (w *ArchiveSpanWriter) WriteItems(items []interface{}) {
 		span := item.(*model.Span)
 		spans = append(spans, span)
 	}
-	w.writeItems(spans)
+	w.retry.Write(spans)
 }
 
-func (w *ArchiveSpanWriter) writeItems(items []*model.Span) {
+// ItemSize implements batch.Sizer
+func (w *ArchiveSpanWriter) ItemSize(item interface{}) int {
+	return dbmodel.EstimateSize(item.(*model.Span))
+}
+
+func (w *ArchiveSpanWriter) giveUp(spans []*model.Span) {
+	if spoolSpans(w.opts.Spool, w.logger, spans) {
+		w.metrics.spansSpooled.Inc(int64(len(spans)))
+		return
+	}
+	w.metrics.spansDropped.Inc(int64(len(spans)))
+	w.dropped.Add(int64(len(spans)))
+}
+
+// Dropped returns the number of spans which are neither written nor spooled
+func (w *ArchiveSpanWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close stops retries, pending spans are spooled if possible
+func (w *ArchiveSpanWriter) Close() {
+	w.retry.Close()
+}
+
+// ReplayItems writes spans saved to spool, spans are kept in spool until write succeeds
+func (w *ArchiveSpanWriter) ReplayItems(items [][]byte) error {
+	spans, err := decodeSpans(items)
+	if err != nil {
+		// can't be fixed by retry
+		w.logger.Error("spooled spans decode error", zap.Error(err))
+		return nil
+	}
+	return w.writeItems(spans)
+}
+
+func (w *ArchiveSpanWriter) writeItems(items []*model.Span) error {
 	spanRecords := make([]types.Value, 0, len(items))
 	for _, span := range items {
 		dbSpan, _ := dbmodel.FromDomain(span)
@@ -65,9 +105,9 @@ func (w *ArchiveSpanWriter) writeItems(items []*model.Span) {
 			"Failed to save spans to archive storage",
 			"error", err,
 		)
-
-		return
+		return err
 	}
+	return nil
 }
 
 func (w *ArchiveSpanWriter) uploadRows(tableName string, rows []types.Value, metrics *wmetrics.WriteMetrics) error {
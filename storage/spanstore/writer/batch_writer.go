@@ -2,6 +2,7 @@ package writer
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -27,31 +28,87 @@ type BatchSpanWriter struct {
 	logger       *zap.Logger
 	jaegerLogger hclog.Logger
 	opts         BatchWriterOptions
+	retry        *retryQueue
+	dropped      atomic.Int64
 }
 
 func NewBatchWriter(pool table.Client, factory metrics.Factory, logger *zap.Logger, jaegerLogger hclog.Logger, opts BatchWriterOptions) *BatchSpanWriter {
-	return &BatchSpanWriter{
+	w := &BatchSpanWriter{
 		pool:         pool,
 		logger:       logger,
 		jaegerLogger: jaegerLogger,
 		opts:         opts,
 		metrics:      newBatchWriterMetrics(factory),
 	}
+	w.retry = newRetryQueue(opts.Retry, w.metrics.traces, logger, w.writeSpans, w.giveUp)
+	return w
 }
 
 func (w *BatchSpanWriter) WriteItems(items []interface{}) {
-	parts := map[schema.PartitionKey][]*model.Span{}
+	spans := make([]*model.Span, 0, len(items))
 	for _, item := range items {
-		span := item.(*model.Span)
+		spans = append(spans, item.(*model.Span))
+	}
+	for _, partial := range splitByPartition(spans) {
+		w.retry.Write(partial)
+	}
+}
+
+// ItemSize implements batch.Sizer
+func (w *BatchSpanWriter) ItemSize(item interface{}) int {
+	return dbmodel.EstimateSize(item.(*model.Span))
+}
+
+// writeSpans writes spans belonging to the same partition
+func (w *BatchSpanWriter) writeSpans(spans []*model.Span) error {
+	return w.writeItemsToPartition(schema.PartitionFromTime(spans[0].StartTime), spans)
+}
+
+func (w *BatchSpanWriter) giveUp(spans []*model.Span) {
+	if spoolSpans(w.opts.Spool, w.logger, spans) {
+		w.metrics.spansSpooled.Inc(int64(len(spans)))
+		return
+	}
+	w.metrics.spansDropped.Inc(int64(len(spans)))
+	w.dropped.Add(int64(len(spans)))
+}
+
+// Dropped returns the number of spans which are neither written nor spooled
+func (w *BatchSpanWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close stops retries, pending spans are spooled if possible
+func (w *BatchSpanWriter) Close() {
+	w.retry.Close()
+}
+
+// ReplayItems writes spans saved to spool, spans are kept in spool until write succeeds
+func (w *BatchSpanWriter) ReplayItems(items [][]byte) error {
+	spans, err := decodeSpans(items)
+	if err != nil {
+		// can't be fixed by retry
+		w.logger.Error("spooled spans decode error", zap.Error(err))
+		return nil
+	}
+	for k, partial := range splitByPartition(spans) {
+		if err = w.writeItemsToPartition(k, partial); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitByPartition(spans []*model.Span) map[schema.PartitionKey][]*model.Span {
+	parts := map[schema.PartitionKey][]*model.Span{}
+	for _, span := range spans {
 		k := schema.PartitionFromTime(span.StartTime)
 		parts[k] = append(parts[k], span)
 	}
-	for k, partial := range parts {
-		w.writeItemsToPartition(k, partial)
-	}
+	return parts
 }
 
-func (w *BatchSpanWriter) writeItemsToPartition(part schema.PartitionKey, items []*model.Span) {
+func (w *BatchSpanWriter) writeItemsToPartition(part schema.PartitionKey, items []*model.Span) error {
 	spanRecords := make([]types.Value, 0, len(items))
 	for _, span := range items {
 		dbSpan, _ := dbmodel.FromDomain(span)
@@ -61,16 +118,50 @@ func (w *BatchSpanWriter) writeItemsToPartition(part schema.PartitionKey, items
 	tableName := func(table string) string {
 		return part.BuildFullTableName(w.opts.DbPath.String(), table)
 	}
-	var err error
-
-	if err = w.uploadRows(tableName(tblTraces), spanRecords, w.metrics.traces); err != nil {
+	err := w.uploadRows(tableName(tblTraces), spanRecords, w.metrics.traces)
+	if db.IsPathNotExistError(err) && w.createPartition(part) {
+		err = w.uploadRows(tableName(tblTraces), spanRecords, w.metrics.traces)
+	}
+	if err != nil {
 		w.logger.Error("insertSpan error", zap.Error(err))
 		w.jaegerLogger.Error(
 			"Failed to save spans",
 			"error", err,
 		)
-		return
+		return err
+	}
+	return nil
+}
+
+// createPartition creates partition which tables don't exist, partitions far from now are left to expire
+func (w *BatchSpanWriter) createPartition(part schema.PartitionKey) bool {
+	if w.opts.Partitions == nil {
+		return false
+	}
+	ctx := context.Background()
+	if w.opts.WriteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.opts.WriteTimeout)
+		defer cancel()
+	}
+	err := w.opts.Partitions.CreateMissing(ctx, part)
+	switch {
+	case err == schema.ErrPartitionOutOfRange:
+		w.metrics.partitionsRejected.Inc(1)
+		w.logger.Warn("partition is out of range, not created", zap.String("suffix", part.Suffix()))
+		return false
+	case err != nil:
+		w.logger.Error("create partition error", zap.String("suffix", part.Suffix()), zap.Error(err))
+		w.jaegerLogger.Error(
+			"Failed to create partition",
+			"suffix", part.Suffix(),
+			"error", err,
+		)
+		return false
 	}
+	w.metrics.partitionsCreated.Inc(1)
+	w.logger.Info("partition created", zap.String("suffix", part.Suffix()))
+	return true
 }
 
 func (w *BatchSpanWriter) uploadRows(tableName string, rows []types.Value, metrics *wmetrics.WriteMetrics) error {
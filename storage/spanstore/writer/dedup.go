@@ -0,0 +1,102 @@
+package writer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// spanDedupKey includes service name, so client and server halves of zipkin-style shared span aren't duplicates
+type spanDedupKey struct {
+	traceID model.TraceID
+	spanID  model.SpanID
+	service string
+}
+
+func newSpanDedupKey(span *model.Span) spanDedupKey {
+	return spanDedupKey{
+		traceID: span.TraceID,
+		spanID:  span.SpanID,
+		service: span.GetProcess().GetServiceName(),
+	}
+}
+
+type spanDedupMetrics struct {
+	hits   metrics.Counter
+	misses metrics.Counter
+}
+
+// spanDedup remembers spans seen within last one or two windows.
+// Generation is rotated when window passes or it grows up to maxSpans/2 items, so memory stays bounded
+// at the cost of forgetting spans early under heavy load.
+type spanDedup struct {
+	window   time.Duration
+	maxSpans int
+	metrics  spanDedupMetrics
+
+	mu        sync.Mutex
+	cur       map[spanDedupKey]struct{}
+	prev      map[spanDedupKey]struct{}
+	rotatedAt time.Time
+}
+
+const defaultDedupMaxSpans = 100000
+
+func newSpanDedup(window time.Duration, maxSpans int, mf metrics.Factory) *spanDedup {
+	if maxSpans < 2 {
+		maxSpans = defaultDedupMaxSpans
+	}
+	return &spanDedup{
+		window:   window,
+		maxSpans: maxSpans,
+		metrics: spanDedupMetrics{
+			hits:   mf.Counter(metrics.Options{Name: "hits"}),
+			misses: mf.Counter(metrics.Options{Name: "misses"}),
+		},
+		cur:       make(map[spanDedupKey]struct{}),
+		prev:      make(map[spanDedupKey]struct{}),
+		rotatedAt: time.Now(),
+	}
+}
+
+// Contains checks if span was seen already, it doesn't remember span
+func (d *spanDedup) Contains(k spanDedupKey) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maybeRotate()
+	_, found := d.cur[k]
+	if !found {
+		_, found = d.prev[k]
+	}
+	if found {
+		d.metrics.hits.Inc(1)
+	} else {
+		d.metrics.misses.Inc(1)
+	}
+	return found
+}
+
+// Add remembers span, it should be called after span is written successfully
+func (d *spanDedup) Add(k spanDedupKey) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maybeRotate()
+	d.cur[k] = struct{}{}
+	if len(d.cur) >= d.maxSpans/2 {
+		d.rotate()
+	}
+}
+
+func (d *spanDedup) maybeRotate() {
+	if time.Since(d.rotatedAt) >= d.window {
+		d.rotate()
+	}
+}
+
+func (d *spanDedup) rotate() {
+	d.prev = d.cur
+	d.cur = make(map[spanDedupKey]struct{}, len(d.prev))
+	d.rotatedAt = time.Now()
+}
@@ -0,0 +1,51 @@
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestSpanDedup(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	d := newSpanDedup(time.Hour, 4, mf)
+	newKey := func(id uint64, svc string) spanDedupKey {
+		return newSpanDedupKey(&model.Span{
+			TraceID: model.NewTraceID(1, 42),
+			SpanID:  model.NewSpanID(id),
+			Process: model.NewProcess(svc, nil),
+		})
+	}
+
+	assert.False(t, d.Contains(newKey(1, "client")))
+	d.Add(newKey(1, "client"))
+	assert.True(t, d.Contains(newKey(1, "client")))
+	// shared span reported by server
+	assert.False(t, d.Contains(newKey(1, "server")))
+	d.Add(newKey(1, "server"))
+
+	// generation is full, both spans are kept in previous one
+	assert.True(t, d.Contains(newKey(1, "client")))
+	d.Add(newKey(2, "client"))
+	d.Add(newKey(3, "client"))
+	assert.False(t, d.Contains(newKey(1, "client")))
+	assert.True(t, d.Contains(newKey(3, "client")))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "hits", Value: 3},
+		metricstest.ExpectedMetric{Name: "misses", Value: 3},
+	)
+}
+
+func TestSpanDedup_Window(t *testing.T) {
+	d := newSpanDedup(time.Hour, 100, metricstest.NewFactory(0))
+	k := spanDedupKey{spanID: 1}
+	d.Add(k)
+	d.rotatedAt = time.Now().Add(-time.Hour)
+	assert.True(t, d.Contains(k))
+	d.rotatedAt = time.Now().Add(-time.Hour)
+	assert.False(t, d.Contains(k))
+}
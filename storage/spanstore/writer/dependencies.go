@@ -0,0 +1,212 @@
+package writer
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/dependencystore"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+type depSpanKey struct {
+	traceID model.TraceID
+	spanID  model.SpanID
+}
+
+type depSpan struct {
+	service  string
+	client   bool
+	server   bool
+	parentID model.SpanID
+	resolved bool
+	// bucket is dependency bucket of span start time, links are counted in bucket of child span
+	bucket int64
+}
+
+type dependencyMetrics struct {
+	spansDropped metrics.Counter
+	linksDropped metrics.Counter
+	links        metrics.Counter
+}
+
+// dependencyAggregator counts caller/callee service pairs seen within two consecutive windows
+// and periodically flushes them to dependencies table, links are bucketed by span start time
+// the same way dependencies job does it, so late spans don't land in buckets of flush time
+type dependencyAggregator struct {
+	writer       *dependencystore.Writer
+	logger       *zap.Logger
+	jaegerLogger hclog.Logger
+	metrics      dependencyMetrics
+	maxSpans     int
+	maxLinks     int
+
+	mu    sync.Mutex
+	cur   map[depSpanKey]*depSpan
+	prev  map[depSpanKey]*depSpan
+	links map[int64]map[dependencystore.Link]uint64
+	// numLinks is total number of links of all buckets
+	numLinks int
+
+	ticker *time.Ticker
+	doneCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newDependencyAggregator(writer *dependencystore.Writer, mf metrics.Factory, logger *zap.Logger, jaegerLogger hclog.Logger, opts SpanWriterOptions) *dependencyAggregator {
+	a := &dependencyAggregator{
+		writer:       writer,
+		logger:       logger,
+		jaegerLogger: jaegerLogger,
+		metrics: dependencyMetrics{
+			spansDropped: mf.Counter(metrics.Options{Name: "spans_dropped"}),
+			linksDropped: mf.Counter(metrics.Options{Name: "links_dropped"}),
+			links:        mf.Counter(metrics.Options{Name: "links"}),
+		},
+		maxSpans: opts.DependencyMaxSpans,
+		maxLinks: opts.DependencyMaxLinks,
+		cur:      make(map[depSpanKey]*depSpan),
+		prev:     make(map[depSpanKey]*depSpan),
+		links:    make(map[int64]map[dependencystore.Link]uint64),
+		ticker:   time.NewTicker(opts.DependencyWindow),
+		doneCh:   make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go a.flushProcess()
+	return a
+}
+
+func (a *dependencyAggregator) Add(span *model.Span) {
+	v := &depSpan{
+		service: span.GetProcess().GetServiceName(),
+		client:  span.IsRPCClient(),
+		server:  span.IsRPCServer(),
+		bucket:  dbmodel.DependencyBucket(span.StartTime),
+	}
+	v.parentID, _ = dependencystore.ParentSpanID(span)
+	k := depSpanKey{traceID: span.TraceID, spanID: span.SpanID}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if other := a.lookup(k); other != nil && other.service != v.service {
+		// zipkin-style shared span: client and server report the same span id
+		switch {
+		case other.client && v.server:
+			a.addLink(v.bucket, other.service, v.service)
+		case other.server && v.client:
+			a.addLink(other.bucket, v.service, other.service)
+		}
+		if !v.server {
+			return
+		}
+		if !other.resolved {
+			a.resolve(span.TraceID, other)
+		}
+		v.resolved = true
+	}
+	if v.parentID == 0 {
+		v.resolved = true
+	} else if !v.resolved {
+		a.resolve(span.TraceID, v)
+	}
+	if _, exists := a.cur[k]; !exists && len(a.cur) >= a.maxSpans {
+		a.metrics.spansDropped.Inc(1)
+		return
+	}
+	a.cur[k] = v
+}
+
+func (a *dependencyAggregator) lookup(k depSpanKey) *depSpan {
+	if v, ok := a.cur[k]; ok {
+		return v
+	}
+	return a.prev[k]
+}
+
+func (a *dependencyAggregator) resolve(traceID model.TraceID, v *depSpan) {
+	parent := a.lookup(depSpanKey{traceID: traceID, spanID: v.parentID})
+	if parent == nil {
+		return
+	}
+	v.resolved = true
+	if parent.service != v.service {
+		a.addLink(v.bucket, parent.service, v.service)
+	}
+}
+
+func (a *dependencyAggregator) addLink(bucket int64, parent, child string) {
+	l := dependencystore.Link{Parent: parent, Child: child}
+	links, ok := a.links[bucket]
+	if _, exists := links[l]; !exists && a.numLinks >= a.maxLinks {
+		a.metrics.linksDropped.Inc(1)
+		return
+	}
+	if !ok {
+		links = make(map[dependencystore.Link]uint64)
+		a.links[bucket] = links
+	}
+	if _, exists := links[l]; !exists {
+		a.numLinks++
+	}
+	links[l]++
+	a.metrics.links.Inc(1)
+}
+
+// rotate resolves children that arrived before their parents and swaps windows
+func (a *dependencyAggregator) rotate() map[int64]map[dependencystore.Link]uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, gen := range []map[depSpanKey]*depSpan{a.prev, a.cur} {
+		for k, v := range gen {
+			if !v.resolved {
+				a.resolve(k.traceID, v)
+			}
+		}
+	}
+	links := a.links
+	a.links = make(map[int64]map[dependencystore.Link]uint64, len(links))
+	a.numLinks = 0
+	a.prev = a.cur
+	a.cur = make(map[depSpanKey]*depSpan, len(a.prev))
+	return links
+}
+
+func (a *dependencyAggregator) flush() {
+	// the same uniq for every bucket of flush, rows of another flush or writer are summed up by reader
+	uniq := rand.Uint32()
+	for bucket, links := range a.rotate() {
+		err := a.writer.WriteLinks(context.Background(), time.Unix(0, bucket), uniq, links)
+		if err != nil {
+			a.logger.Error("dependencies write fail", zap.Time("bucket", time.Unix(0, bucket)), zap.Int("links", len(links)), zap.Error(err))
+			a.jaegerLogger.Error(
+				"Failed to save dependencies",
+				"error", err,
+			)
+		}
+	}
+}
+
+func (a *dependencyAggregator) flushProcess() {
+	defer a.wg.Done()
+	for {
+		select {
+		case <-a.doneCh:
+			return
+		case <-a.ticker.C:
+			a.flush()
+		}
+	}
+}
+
+func (a *dependencyAggregator) Close() {
+	a.ticker.Stop()
+	close(a.doneCh)
+	a.wg.Wait()
+	a.flush()
+}
@@ -0,0 +1,73 @@
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/testutil"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/dependencystore"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+func TestDependencyAggregator(t *testing.T) {
+	a := newDependencyAggregator(nil, metrics.NullFactory, testutil.Zap(), testutil.JaegerLogger(), SpanWriterOptions{
+		DependencyWindow:   time.Hour,
+		DependencyMaxSpans: 100,
+		DependencyMaxLinks: 1,
+	})
+	defer a.ticker.Stop()
+
+	traceID := model.NewTraceID(1, 42)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := dbmodel.DependencyBucket(start)
+	newSpan := func(id, parent uint64, svc string) *model.Span {
+		s := &model.Span{
+			TraceID:   traceID,
+			SpanID:    model.NewSpanID(id),
+			Process:   model.NewProcess(svc, nil),
+			StartTime: start,
+		}
+		if parent != 0 {
+			s.References = []model.SpanRef{model.NewChildOfRef(traceID, model.NewSpanID(parent))}
+		}
+		return s
+	}
+
+	a.Add(newSpan(1, 0, "frontend"))
+	a.Add(newSpan(2, 1, "backend"))
+	// parent arrives in the next window
+	a.Add(newSpan(4, 3, "db"))
+	assert.Equal(t, map[int64]map[dependencystore.Link]uint64{
+		bucket: {{Parent: "frontend", Child: "backend"}: 1},
+	}, a.rotate())
+
+	a.Add(newSpan(3, 2, "backend"))
+	assert.Equal(t, map[int64]map[dependencystore.Link]uint64{
+		bucket: {{Parent: "backend", Child: "db"}: 1},
+	}, a.rotate())
+
+	a.Add(newSpan(10, 0, "frontend"))
+	a.Add(newSpan(11, 10, "backend"))
+	// link limit reached, frontend->cache pair is dropped
+	a.Add(newSpan(12, 10, "cache"))
+	assert.Equal(t, map[int64]map[dependencystore.Link]uint64{
+		bucket: {{Parent: "frontend", Child: "backend"}: 1},
+	}, a.rotate())
+
+	// links are bucketed by start time of child span, not by flush time,
+	// the same pair in two buckets takes two places of the limit
+	a.maxLinks = 2
+	a.Add(newSpan(20, 0, "frontend"))
+	late := newSpan(21, 20, "backend")
+	late.StartTime = start.Add(-dbmodel.DependencyBucketStep)
+	a.Add(late)
+	a.Add(newSpan(22, 20, "backend"))
+	assert.Equal(t, map[int64]map[dependencystore.Link]uint64{
+		bucket - int64(dbmodel.DependencyBucketStep): {{Parent: "frontend", Child: "backend"}: 1},
+		bucket: {{Parent: "frontend", Child: "backend"}: 1},
+	}, a.rotate())
+}
@@ -11,12 +11,20 @@ import (
 type batchWriterMetrics struct {
 	traces       *wmetrics.WriteMetrics
 	spansDropped metrics.Counter
+	spansSpooled metrics.Counter
+
+	partitionsCreated  metrics.Counter
+	partitionsRejected metrics.Counter
 }
 
 func newBatchWriterMetrics(factory metrics.Factory) batchWriterMetrics {
 	return batchWriterMetrics{
 		traces:       wmetrics.NewWriteMetrics(factory, "traces"),
 		spansDropped: factory.Counter(metrics.Options{Name: "spans_dropped"}),
+		spansSpooled: factory.Counter(metrics.Options{Name: "spans_spooled"}),
+
+		partitionsCreated:  factory.Counter(metrics.Options{Name: "partitions_created"}),
+		partitionsRejected: factory.Counter(metrics.Options{Name: "partitions_rejected"}),
 	}
 }
 
@@ -14,6 +14,8 @@ type WriteMetrics struct {
 	LatencyErr metrics.Timer   `metric:"latency-err"`
 	RecordsOk  metrics.Counter `metric:"records-ok"`
 	RecordsErr metrics.Counter `metric:"records-err"`
+
+	RecordsRetried metrics.Counter `metric:"records-retried"`
 }
 
 func NewWriteMetrics(factory metrics.Factory, tableName string) *WriteMetrics {
@@ -0,0 +1,160 @@
+package writer
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+	wmetrics "github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer/metrics"
+)
+
+const (
+	namesBufferSize = 1000
+	namesBatchSize  = 100
+
+	tblServiceNames   = "service_names"
+	tblOperationNames = "operation_names_v2"
+)
+
+// nameItem is a row of service_names table if operation is empty, otherwise a row of operation_names_v2 table
+type nameItem struct {
+	service   string
+	operation string
+	spanKind  string
+}
+
+func (n nameItem) cacheKey() string {
+	if n.operation == "" {
+		return n.service
+	}
+	return n.service + "-" + n.operation + "-" + n.spanKind
+}
+
+// nameWriter registers service and operation names in background, names are deduplicated by LRU cache
+// and upserted in batches every flush interval. Archive writer registers names in archive_ tables.
+type nameWriter struct {
+	pool         table.Client
+	logger       *zap.Logger
+	jaegerLogger hclog.Logger
+	opts         SpanWriterOptions
+	cache        *lru.Cache
+	tblPrefix    string
+	queue        *batch.Queue
+	services     *wmetrics.WriteMetrics
+	operations   *wmetrics.WriteMetrics
+}
+
+func newNameWriter(pool table.Client, mf metrics.Factory, logger *zap.Logger, jaegerLogger hclog.Logger, opts SpanWriterOptions) *nameWriter {
+	cache, _ := lru.New(opts.OpCacheSize) // it's ok to ignore this error for negative size
+	w := &nameWriter{
+		pool:         pool,
+		logger:       logger,
+		jaegerLogger: jaegerLogger,
+		opts:         opts,
+		cache:        cache,
+		services:     wmetrics.NewWriteMetrics(mf, "service_names"),
+		operations:   wmetrics.NewWriteMetrics(mf, "operation_names"),
+	}
+	if opts.ArchiveWriter {
+		w.tblPrefix = tblArchive + "_"
+	}
+	w.queue = batch.NewQueue(batch.Options{
+		BufferSize:    namesBufferSize,
+		BatchSize:     namesBatchSize,
+		BatchWorkers:  1,
+		FlushInterval: opts.NamesFlushInterval,
+		Overflow:      batch.OverflowDrop,
+	}, mf, w)
+	return w
+}
+
+// Add queues names of span which weren't registered yet
+func (w *nameWriter) Add(span *model.Span) {
+	service := nameItem{service: span.GetProcess().GetServiceName()}
+	w.add(service)
+	if span.GetOperationName() == "" {
+		return
+	}
+	kind, _ := span.GetSpanKind()
+	w.add(nameItem{service: service.service, operation: span.GetOperationName(), spanKind: kind.String()})
+}
+
+func (w *nameWriter) add(item nameItem) {
+	if exists, _ := w.cache.ContainsOrAdd(item.cacheKey(), true); exists {
+		return
+	}
+	if err := w.queue.Add(item); err != nil {
+		// registered again with the next span
+		w.cache.Remove(item.cacheKey())
+	}
+}
+
+func (w *nameWriter) WriteItems(items []interface{}) {
+	var services, operations []nameItem
+	for _, item := range items {
+		n := item.(nameItem)
+		if n.operation == "" {
+			services = append(services, n)
+		} else {
+			operations = append(operations, n)
+		}
+	}
+	if len(services) > 0 {
+		rows := make([]types.Value, 0, len(services))
+		for _, n := range services {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("service_name", types.TextValue(n.service)),
+			))
+		}
+		w.upsert(w.tblPrefix+tblServiceNames, rows, services, w.services)
+	}
+	if len(operations) > 0 {
+		rows := make([]types.Value, 0, len(operations))
+		for _, n := range operations {
+			rows = append(rows, types.StructValue(
+				types.StructFieldValue("service_name", types.TextValue(n.service)),
+				types.StructFieldValue("operation_name", types.TextValue(n.operation)),
+				types.StructFieldValue("span_kind", types.TextValue(n.spanKind)),
+			))
+		}
+		w.upsert(w.tblPrefix+tblOperationNames, rows, operations, w.operations)
+	}
+}
+
+func (w *nameWriter) upsert(tbl string, rows []types.Value, items []nameItem, m *wmetrics.WriteMetrics) {
+	ctx := context.Background()
+	if w.opts.WriteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.opts.WriteTimeout)
+		defer cancel()
+	}
+	ts := time.Now()
+	err := db.UpsertData(ctx, w.pool, w.opts.DbPath.FullTable(tbl), types.ListValue(rows...), w.opts.RetryAttemptTimeout)
+	m.Emit(err, time.Since(ts), len(rows))
+	if err != nil {
+		// forget names, so they are registered again with the next span
+		for _, n := range items {
+			w.cache.Remove(n.cacheKey())
+		}
+		w.logger.Error("names write error", zap.String("table", tbl), zap.Error(err))
+		w.jaegerLogger.Error(
+			"Failed to save names",
+			"table", tbl,
+			"error", err,
+		)
+	}
+}
+
+// Close flushes queued names until ctx is done
+func (w *nameWriter) Close(ctx context.Context) batch.DrainStats {
+	return w.queue.Close(ctx)
+}
@@ -0,0 +1,53 @@
+package writer
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+)
+
+type nameRecorder struct {
+	mu    sync.Mutex
+	items []nameItem
+}
+
+func (r *nameRecorder) WriteItems(items []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range items {
+		r.items = append(r.items, item.(nameItem))
+	}
+}
+
+func TestNameWriter_Add(t *testing.T) {
+	cache, _ := lru.New(100)
+	rec := &nameRecorder{}
+	w := &nameWriter{cache: cache}
+	w.queue = batch.NewQueue(batch.Options{BatchSize: 10, BatchWorkers: 1}, metricstest.NewFactory(0), rec)
+	newSpan := func(svc, op string) *model.Span {
+		return &model.Span{
+			OperationName: op,
+			Process:       model.NewProcess(svc, nil),
+			Tags:          []model.KeyValue{model.String("span.kind", "server")},
+		}
+	}
+	w.Add(newSpan("svc1", "op1"))
+	w.Add(newSpan("svc1", "op1"))
+	w.Add(newSpan("svc1", "op2"))
+	w.Add(newSpan("svc2", ""))
+	w.Close(context.Background())
+
+	assert.ElementsMatch(t, []nameItem{
+		{service: "svc1"},
+		{service: "svc1", operation: "op1", spanKind: "server"},
+		{service: "svc1", operation: "op2", spanKind: "server"},
+		{service: "svc2"},
+	}, rec.items)
+}
@@ -4,18 +4,30 @@ import (
 	"time"
 
 	"github.com/ydb-platform/jaeger-ydb-store/schema"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/indexer"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/ratelimit"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/redaction"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/sampling"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/spool"
 )
 
 type BatchWriterOptions struct {
 	DbPath              schema.DbPath
 	WriteTimeout        time.Duration
 	RetryAttemptTimeout time.Duration
+	Spool               *spool.Spool
+	Retry               RetryOptions
+	// Partitions creates partitions missing at write time, optional
+	Partitions *schema.PartitionCreator
 }
 
 type SpanWriterOptions struct {
 	BufferSize          int
 	BatchSize           int
 	BatchWorkers        int
+	BatchBytes          int
+	FlushInterval       time.Duration
 	IndexerBufferSize   int
 	IndexerMaxTraces    int
 	IndexerTTL          time.Duration
@@ -24,5 +36,55 @@ type SpanWriterOptions struct {
 	RetryAttemptTimeout time.Duration
 	ArchiveWriter       bool
 	OpCacheSize         int
-	MaxSpanAge          time.Duration
+	// NamesFlushInterval is the period of service and operation names registration
+	NamesFlushInterval time.Duration
+	MaxSpanAge         time.Duration
+	// MaxFutureSkew enables FutureSkewPolicy for spans starting later than now + MaxFutureSkew
+	MaxFutureSkew    time.Duration
+	FutureSkewPolicy FutureSkewPolicy
+
+	// DependencyWindow enables dependency links aggregation when set
+	DependencyWindow   time.Duration
+	DependencyMaxSpans int
+	DependencyMaxLinks int
+
+	// DedupWindow enables skipping indexing of spans seen within last window
+	DedupWindow   time.Duration
+	DedupMaxSpans int
+	// DedupSpans skips writing duplicate spans to traces table as well
+	DedupSpans bool
+
+	// Overflow controls WriteSpan behaviour when span or indexer buffer is full
+	Overflow        batch.OverflowPolicy
+	OverflowTimeout time.Duration
+
+	// Retry controls re-sending of failed span batches
+	Retry RetryOptions
+
+	// Redactor removes sensitive data from spans, optional
+	Redactor *redaction.Redactor
+
+	// TagPolicy selects tags to index, every tag except binary ones is indexed if not set
+	TagPolicy *indexer.TagPolicySource
+
+	// Limits truncate oversized spans
+	Limits SpanLimits
+
+	// RateLimiter drops spans of services exceeding their rate, primary writer only, optional
+	RateLimiter *ratelimit.Limiter
+
+	// Sampler enables tail-based sampling for primary writer, optional.
+	// Sampled spans are written after WriteSpan has returned, so Overflow should be batch.OverflowDrop.
+	Sampler *sampling.Sampler
+
+	// Spool keeps spans which can't be written right now on local disk, optional
+	Spool *spool.Spool
+
+	// Partitions creates partitions missing at write time, optional
+	Partitions *schema.PartitionCreator
+
+	// Secondary receives every span accepted by this writer, even dropped ones, e.g. to fill another database during migration, optional.
+	// Spans are handed off through a queue of BufferSize, spans which don't fit are counted by secondary_dropped metric.
+	// Spans rejected by this writer aren't passed, collector retries them.
+	Secondary *SpanWriter
 }
@@ -0,0 +1,165 @@
+package writer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	wmetrics "github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer/metrics"
+)
+
+const (
+	defaultRetryInitialInterval = time.Second
+	defaultRetryMaxInterval     = 30 * time.Second
+	defaultRetryQueueSize       = 100
+)
+
+type RetryOptions struct {
+	// MaxAttempts is the number of writes made for a batch including the first one, retries are disabled if <= 1
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// QueueSize is the number of batches waiting for retry
+	QueueSize int
+}
+
+type retryBatch struct {
+	spans   []*model.Span
+	attempt int
+	readyAt time.Time
+}
+
+// retryQueue writes span batches, splits them on request size errors
+// and re-sends failed ones with exponential backoff.
+// Batches which are out of attempts or don't fit into queue are passed to giveUp,
+// it spools spans or counts them as dropped.
+type retryQueue struct {
+	opts    RetryOptions
+	logger  *zap.Logger
+	metrics *wmetrics.WriteMetrics
+	write   func(spans []*model.Span) error
+	giveUp  func(spans []*model.Span)
+
+	queue  chan retryBatch
+	doneCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newRetryQueue(opts RetryOptions, m *wmetrics.WriteMetrics, logger *zap.Logger, write func(spans []*model.Span) error, giveUp func(spans []*model.Span)) *retryQueue {
+	if opts.InitialInterval <= 0 {
+		opts.InitialInterval = defaultRetryInitialInterval
+	}
+	if opts.MaxInterval < opts.InitialInterval {
+		opts.MaxInterval = defaultRetryMaxInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultRetryQueueSize
+	}
+	q := &retryQueue{
+		opts:    opts,
+		logger:  logger,
+		metrics: m,
+		write:   write,
+		giveUp:  giveUp,
+		doneCh:  make(chan struct{}),
+	}
+	if opts.MaxAttempts > 1 {
+		q.queue = make(chan retryBatch, opts.QueueSize)
+		q.wg.Add(1)
+		go q.retryProcess()
+	}
+	return q
+}
+
+// Write makes the first attempt to write spans in caller goroutine
+func (q *retryQueue) Write(spans []*model.Span) {
+	q.process(retryBatch{spans: spans, attempt: 1})
+}
+
+func (q *retryQueue) process(b retryBatch) {
+	err := q.write(b.spans)
+	switch {
+	case err == nil:
+	case db.IsRequestSizeError(err) && len(b.spans) > 1:
+		half := len(b.spans) / 2
+		q.process(retryBatch{spans: b.spans[:half], attempt: b.attempt})
+		q.process(retryBatch{spans: b.spans[half:], attempt: b.attempt})
+	default:
+		q.retry(b)
+	}
+}
+
+func (q *retryQueue) retry(b retryBatch) {
+	if q.queue == nil || b.attempt >= q.opts.MaxAttempts {
+		q.drop(b.spans)
+		return
+	}
+	b.readyAt = time.Now().Add(q.backoff(b.attempt))
+	b.attempt++
+	select {
+	case <-q.doneCh:
+		q.drop(b.spans)
+	case q.queue <- b:
+		q.metrics.RecordsRetried.Inc(int64(len(b.spans)))
+	default:
+		q.logger.Warn("retry queue is full", zap.Int("spans", len(b.spans)))
+		q.drop(b.spans)
+	}
+}
+
+func (q *retryQueue) backoff(attempt int) time.Duration {
+	d := q.opts.InitialInterval
+	for i := 1; i < attempt && d < q.opts.MaxInterval; i++ {
+		d *= 2
+	}
+	if d > q.opts.MaxInterval {
+		d = q.opts.MaxInterval
+	}
+	return d
+}
+
+func (q *retryQueue) drop(spans []*model.Span) {
+	q.giveUp(spans)
+}
+
+func (q *retryQueue) retryProcess() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.doneCh:
+			return
+		case b := <-q.queue:
+			if wait := time.Until(b.readyAt); wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-q.doneCh:
+					t.Stop()
+					q.drop(b.spans)
+					return
+				case <-t.C:
+				}
+			}
+			q.process(b)
+		}
+	}
+}
+
+// Close stops retries, pending batches are passed to giveUp
+func (q *retryQueue) Close() {
+	close(q.doneCh)
+	q.wg.Wait()
+	if q.queue == nil {
+		return
+	}
+	for {
+		select {
+		case b := <-q.queue:
+			q.drop(b.spans)
+		default:
+			return
+		}
+	}
+}
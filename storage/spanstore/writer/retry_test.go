@@ -0,0 +1,101 @@
+package writer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/ydb-platform/jaeger-ydb-store/internal/testutil"
+	wmetrics "github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/writer/metrics"
+)
+
+func TestRetryQueue(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		failures = 2
+		written  []*model.Span
+		lost     []*model.Span
+	)
+	write := func(spans []*model.Span) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			return errors.New("overloaded")
+		}
+		written = append(written, spans...)
+		return nil
+	}
+	giveUp := func(spans []*model.Span) {
+		mu.Lock()
+		defer mu.Unlock()
+		lost = append(lost, spans...)
+	}
+	mf := metricstest.NewFactory(0)
+	q := newRetryQueue(RetryOptions{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+	}, wmetrics.NewWriteMetrics(mf, "traces"), testutil.Zap(), write, giveUp)
+
+	spans := []*model.Span{{SpanID: 1}, {SpanID: 2}}
+	q.Write(spans)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(written) == 2
+	}, time.Second, time.Millisecond)
+	q.Close()
+	assert.Empty(t, lost)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "traces.records-retried", Value: 4},
+	)
+
+	// out of attempts
+	failures = 3
+	q = newRetryQueue(RetryOptions{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+	}, wmetrics.NewWriteMetrics(mf, "archive"), testutil.Zap(), write, giveUp)
+	q.Write(spans)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(lost) == 2
+	}, time.Second, time.Millisecond)
+	q.Close()
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "archive.records-retried", Value: 4},
+	)
+}
+
+func TestRetryQueue_Disabled(t *testing.T) {
+	var lost int
+	q := newRetryQueue(RetryOptions{}, wmetrics.NewWriteMetrics(metrics.NullFactory, "traces"), testutil.Zap(),
+		func(spans []*model.Span) error {
+			return errors.New("overloaded")
+		},
+		func(spans []*model.Span) {
+			lost += len(spans)
+		},
+	)
+	q.Write([]*model.Span{{SpanID: 1}})
+	q.Close()
+	assert.Equal(t, 1, lost)
+}
+
+func TestRetryQueue_Backoff(t *testing.T) {
+	q := &retryQueue{opts: RetryOptions{InitialInterval: time.Second, MaxInterval: 5 * time.Second}}
+	assert.Equal(t, time.Second, q.backoff(1))
+	assert.Equal(t, 2*time.Second, q.backoff(2))
+	assert.Equal(t, 4*time.Second, q.backoff(3))
+	assert.Equal(t, 5*time.Second, q.backoff(4))
+	assert.Equal(t, 5*time.Second, q.backoff(40))
+}
@@ -0,0 +1,37 @@
+package writer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// FutureSkewPolicy controls what WriteSpan does with span starting too far in the future
+type FutureSkewPolicy string
+
+const (
+	// FutureSkewReject drops span the same way as spans older than MaxSpanAge
+	FutureSkewReject FutureSkewPolicy = "reject"
+	// FutureSkewClamp moves span start to receive time and adds warning to span
+	FutureSkewClamp FutureSkewPolicy = "clamp"
+)
+
+// ParseFutureSkewPolicy validates policy name, empty name means FutureSkewReject
+func ParseFutureSkewPolicy(s string) (FutureSkewPolicy, error) {
+	switch p := FutureSkewPolicy(s); p {
+	case "":
+		return FutureSkewReject, nil
+	case FutureSkewReject, FutureSkewClamp:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown future skew policy '%s'", s)
+	}
+}
+
+// clampStartTime moves span start to now, duration is kept
+func clampStartTime(span *model.Span, now time.Time) {
+	span.Warnings = append(span.Warnings, fmt.Sprintf("start time clamped to receive time: it was %s ahead, check clock skew",
+		span.StartTime.Sub(now).Round(time.Millisecond)))
+	span.StartTime = now
+}
@@ -0,0 +1,29 @@
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFutureSkewPolicy(t *testing.T) {
+	p, err := ParseFutureSkewPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, FutureSkewReject, p)
+	p, err = ParseFutureSkewPolicy("clamp")
+	assert.NoError(t, err)
+	assert.Equal(t, FutureSkewClamp, p)
+	_, err = ParseFutureSkewPolicy("shift")
+	assert.Error(t, err)
+}
+
+func TestClampStartTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	span := &model.Span{StartTime: now.Add(48 * time.Hour), Duration: time.Second}
+	clampStartTime(span, now)
+	assert.Equal(t, now, span.StartTime)
+	assert.Equal(t, time.Second, span.Duration)
+	assert.Equal(t, []string{"start time clamped to receive time: it was 48h0m0s ahead, check clock skew"}, span.Warnings)
+}
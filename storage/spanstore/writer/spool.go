@@ -0,0 +1,50 @@
+package writer
+
+import (
+	"fmt"
+
+	"github.com/jaegertracing/jaeger/model"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/spool"
+)
+
+func encodeSpans(spans []*model.Span) ([][]byte, error) {
+	items := make([][]byte, 0, len(spans))
+	for _, span := range spans {
+		buf, err := span.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("span marshal error: %w", err)
+		}
+		items = append(items, buf)
+	}
+	return items, nil
+}
+
+func decodeSpans(items [][]byte) ([]*model.Span, error) {
+	spans := make([]*model.Span, 0, len(items))
+	for _, item := range items {
+		span := &model.Span{}
+		if err := span.Unmarshal(item); err != nil {
+			return nil, fmt.Errorf("span unmarshal error: %w", err)
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// spoolSpans saves spans to spool if it's configured, returns false if spans are lost
+func spoolSpans(sp *spool.Spool, logger *zap.Logger, spans []*model.Span) bool {
+	if sp == nil {
+		return false
+	}
+	items, err := encodeSpans(spans)
+	if err == nil {
+		err = sp.Write(items)
+	}
+	if err != nil {
+		logger.Error("spool write error", zap.Int("spans", len(spans)), zap.Error(err))
+		return false
+	}
+	return true
+}
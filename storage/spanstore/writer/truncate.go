@@ -0,0 +1,197 @@
+package writer
+
+import (
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+const (
+	truncateTagValue = "tag_value"
+	truncateTags     = "tags"
+	truncateLogs     = "logs"
+	truncateSize     = "size"
+
+	truncateWarningPrefix = "span truncated: "
+)
+
+// SpanLimits bounds span size, zero value of a field means no limit
+type SpanLimits struct {
+	MaxTagValueLength int
+	MaxTags           int
+	MaxLogs           int
+	// MaxSpanBytes limits estimated size of db row, logs and then tags are cut from the end to fit it
+	MaxSpanBytes int
+}
+
+func (l SpanLimits) enabled() bool {
+	return l.MaxTagValueLength > 0 || l.MaxTags > 0 || l.MaxLogs > 0 || l.MaxSpanBytes > 0
+}
+
+type truncateMetricsKey struct {
+	svc    string
+	reason string
+}
+
+type truncateMetrics struct {
+	mf metrics.Factory
+	m  map[truncateMetricsKey]metrics.Counter
+	mu sync.Mutex
+}
+
+func newTruncateMetrics(mf metrics.Factory) *truncateMetrics {
+	return &truncateMetrics{
+		mf: mf,
+		m:  make(map[truncateMetricsKey]metrics.Counter),
+	}
+}
+
+func (m *truncateMetrics) Inc(svc, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := truncateMetricsKey{svc: svc, reason: reason}
+	if _, exists := m.m[k]; !exists {
+		m.m[k] = m.mf.Counter(metrics.Options{Name: "truncated_spans", Tags: map[string]string{"svc": svc, "reason": reason}})
+	}
+	m.m[k].Inc(1)
+}
+
+// spanTruncator cuts spans exceeding limits and records what was cut in span warnings.
+// Slices and process which are changed are replaced with copies, because they may be shared with other spans.
+type spanTruncator struct {
+	limits  SpanLimits
+	metrics *truncateMetrics
+}
+
+func newSpanTruncator(limits SpanLimits, mf metrics.Factory) *spanTruncator {
+	return &spanTruncator{
+		limits:  limits,
+		metrics: newTruncateMetrics(mf),
+	}
+}
+
+func (t *spanTruncator) Apply(span *model.Span) {
+	svc := span.GetProcess().GetServiceName()
+	if n := t.truncateValues(span); n > 0 {
+		t.warn(span, svc, truncateTagValue, fmt.Sprintf("%d tag values truncated to %d bytes", n, t.limits.MaxTagValueLength))
+	}
+	if t.limits.MaxTags > 0 && len(span.Tags) > t.limits.MaxTags {
+		t.warn(span, svc, truncateTags, fmt.Sprintf("%d of %d tags dropped", len(span.Tags)-t.limits.MaxTags, len(span.Tags)))
+		span.Tags = span.Tags[:t.limits.MaxTags:t.limits.MaxTags]
+	}
+	if t.limits.MaxLogs > 0 && len(span.Logs) > t.limits.MaxLogs {
+		t.warn(span, svc, truncateLogs, fmt.Sprintf("%d of %d logs dropped", len(span.Logs)-t.limits.MaxLogs, len(span.Logs)))
+		span.Logs = span.Logs[:t.limits.MaxLogs:t.limits.MaxLogs]
+	}
+	if t.limits.MaxSpanBytes > 0 {
+		t.truncateSize(span, svc)
+	}
+}
+
+func (t *spanTruncator) warn(span *model.Span, svc, reason, msg string) {
+	t.metrics.Inc(svc, reason)
+	span.Warnings = append(span.Warnings, truncateWarningPrefix+msg)
+}
+
+func (t *spanTruncator) truncateValues(span *model.Span) int {
+	if t.limits.MaxTagValueLength <= 0 {
+		return 0
+	}
+	total := 0
+	if tags, n := t.truncateKVs(span.Tags); n > 0 {
+		span.Tags = tags
+		total += n
+	}
+	if span.Process != nil {
+		if tags, n := t.truncateKVs(span.Process.Tags); n > 0 {
+			span.Process = model.NewProcess(span.Process.ServiceName, tags)
+			total += n
+		}
+	}
+	var logs []model.Log
+	for i, l := range span.Logs {
+		fields, n := t.truncateKVs(l.Fields)
+		if n == 0 {
+			continue
+		}
+		if logs == nil {
+			logs = append([]model.Log(nil), span.Logs...)
+		}
+		logs[i].Fields = fields
+		total += n
+	}
+	if logs != nil {
+		span.Logs = logs
+	}
+	return total
+}
+
+// truncateKVs returns copy of kvs with long values cut and number of such values
+func (t *spanTruncator) truncateKVs(kvs []model.KeyValue) ([]model.KeyValue, int) {
+	var result []model.KeyValue
+	n := 0
+	for i, kv := range kvs {
+		switch {
+		case kv.VType == model.StringType && len(kv.VStr) > t.limits.MaxTagValueLength:
+			kv.VStr = truncateString(kv.VStr, t.limits.MaxTagValueLength)
+		case kv.VType == model.BinaryType && len(kv.VBinary) > t.limits.MaxTagValueLength:
+			kv.VBinary = kv.VBinary[:t.limits.MaxTagValueLength:t.limits.MaxTagValueLength]
+		default:
+			continue
+		}
+		if result == nil {
+			result = append([]model.KeyValue(nil), kvs...)
+		}
+		result[i] = kv
+		n++
+	}
+	return result, n
+}
+
+// truncateString cuts s to at most n bytes without breaking utf-8 sequence
+func truncateString(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// truncateSize drops logs and then tags from the end until span fits MaxSpanBytes together with the warning
+func (t *spanTruncator) truncateSize(span *model.Span, svc string) {
+	size := dbmodel.EstimateSize(span)
+	if size <= t.limits.MaxSpanBytes {
+		return
+	}
+	logs, tags := len(span.Logs), len(span.Tags)
+	// warning can only get shorter than this one
+	reserve := fieldSize(len(truncateWarningPrefix) + len(sizeWarning(logs, tags, t.limits.MaxSpanBytes)))
+	for len(span.Logs) > 0 && size+reserve > t.limits.MaxSpanBytes {
+		n := len(span.Logs) - 1
+		size -= fieldSize(span.Logs[n].Size())
+		span.Logs = span.Logs[:n:n]
+	}
+	for len(span.Tags) > 0 && size+reserve > t.limits.MaxSpanBytes {
+		n := len(span.Tags) - 1
+		size -= fieldSize(span.Tags[n].Size())
+		span.Tags = span.Tags[:n:n]
+	}
+	t.warn(span, svc, truncateSize, sizeWarning(logs-len(span.Logs), tags-len(span.Tags), t.limits.MaxSpanBytes))
+}
+
+func sizeWarning(logs, tags, limit int) string {
+	return fmt.Sprintf("%d logs and %d tags dropped to fit %d bytes", logs, tags, limit)
+}
+
+// fieldSize returns encoded size of repeated field element of length l in dbmodel.SpanData
+func fieldSize(l int) int {
+	n := 1
+	for v := uint64(l); v >= 1<<7; v >>= 7 {
+		n++
+	}
+	return 1 + n + l
+}
@@ -0,0 +1,78 @@
+package writer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/dbmodel"
+)
+
+func TestSpanTruncator(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	tr := newSpanTruncator(SpanLimits{MaxTagValueLength: 4, MaxTags: 2, MaxLogs: 1}, mf)
+	process := model.NewProcess("svc", []model.KeyValue{model.String("host", "example.com")})
+	tags := []model.KeyValue{
+		model.String("a", "ab"),
+		model.String("b", "абв"),
+		model.Binary("c", []byte("binary")),
+	}
+	logs := []model.Log{
+		{Fields: []model.KeyValue{model.String("event", "long event")}},
+		{Fields: []model.KeyValue{model.String("event", "e")}},
+	}
+	span := &model.Span{Process: process, Tags: tags, Logs: logs}
+	tr.Apply(span)
+
+	assert.Equal(t, []model.KeyValue{model.String("a", "ab"), model.String("b", "аб")}, span.Tags)
+	assert.Equal(t, []model.KeyValue{model.String("host", "exam")}, span.Process.Tags)
+	assert.Equal(t, []model.Log{{Fields: []model.KeyValue{model.String("event", "long")}}}, span.Logs)
+	assert.Equal(t, []string{
+		"span truncated: 4 tag values truncated to 4 bytes",
+		"span truncated: 1 of 3 tags dropped",
+		"span truncated: 1 of 2 logs dropped",
+	}, span.Warnings)
+	// shared data isn't modified
+	assert.Equal(t, "example.com", process.Tags[0].VStr)
+	assert.Equal(t, "абв", tags[1].VStr)
+	assert.Equal(t, "long event", logs[0].Fields[0].VStr)
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "truncated_spans", Tags: map[string]string{"svc": "svc", "reason": truncateTagValue}, Value: 1},
+		metricstest.ExpectedMetric{Name: "truncated_spans", Tags: map[string]string{"svc": "svc", "reason": truncateTags}, Value: 1},
+		metricstest.ExpectedMetric{Name: "truncated_spans", Tags: map[string]string{"svc": "svc", "reason": truncateLogs}, Value: 1},
+	)
+}
+
+func TestSpanTruncator_Size(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	tr := newSpanTruncator(SpanLimits{MaxSpanBytes: 400}, mf)
+	span := &model.Span{
+		Process: model.NewProcess("svc", nil),
+		Tags:    []model.KeyValue{model.String("a", strings.Repeat("a", 100)), model.String("b", strings.Repeat("b", 100))},
+	}
+	for i := 0; i < 10; i++ {
+		span.Logs = append(span.Logs, model.Log{
+			Timestamp: time.Unix(0, 0),
+			Fields:    []model.KeyValue{model.String("event", strings.Repeat("e", 50))},
+		})
+	}
+	tr.Apply(span)
+
+	assert.LessOrEqual(t, dbmodel.EstimateSize(span), 400)
+	assert.Len(t, span.Logs, 1)
+	assert.Len(t, span.Tags, 2)
+	assert.Equal(t, []string{"span truncated: 9 logs and 0 tags dropped to fit 400 bytes"}, span.Warnings)
+
+	// span within limits is left as is
+	warnings := span.Warnings
+	tr.Apply(span)
+	assert.Equal(t, warnings, span.Warnings)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "truncated_spans", Tags: map[string]string{"svc": "svc", "reason": truncateSize}, Value: 1},
+	)
+}
@@ -2,21 +2,50 @@ package writer
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
-	lru "github.com/hashicorp/golang-lru"
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
-	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
-	"github.com/ydb-platform/jaeger-ydb-store/internal/db"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/dependencystore"
 	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/batch"
 	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/indexer"
+	"github.com/ydb-platform/jaeger-ydb-store/storage/spanstore/sampling"
 )
 
+// ErrBufferFull is returned when buffers are full and overflow policy isn't batch.OverflowDrop
+var ErrBufferFull = status.Error(codes.ResourceExhausted, "writer buffer is full")
+
+// defaultSampledWriteTimeout bounds writes of sampled spans if OverflowTimeout isn't set
+const defaultSampledWriteTimeout = time.Second
+
+type spanBatchWriter interface {
+	batch.Writer
+	ReplayItems(items [][]byte) error
+	Dropped() int64
+	Close()
+}
+
+// CloseStats reports what is written or lost on Close
+type CloseStats struct {
+	Spans batch.DrainStats
+	Names batch.DrainStats
+	Index indexer.DrainStats
+	// DroppedSpans counts spans which failed to write during writer lifetime and weren't spooled
+	DroppedSpans int64
+}
+
+// Lost returns the number of spans and index entries which aren't saved
+func (s CloseStats) Lost() int64 {
+	return s.Spans.Lost + s.DroppedSpans + s.Index.LostSpans + s.Index.Entries.Lost
+}
+
 // SpanWriter handles all span/indexer writes to YDB
 type SpanWriter struct {
 	opts              SpanWriterOptions
@@ -24,30 +53,55 @@ type SpanWriter struct {
 	logger            *zap.Logger
 	jaegerLogger      hclog.Logger
 	spanBatch         *batch.Queue
+	batchWriter       spanBatchWriter
 	indexer           *indexer.Indexer
-	nameCache         *lru.Cache
+	names             *nameWriter
 	invalidateMetrics *invalidSpanMetrics
+	dependencies      *dependencyAggregator
+	dedup             *spanDedup
+	sampler           *sampling.Sampler
+	truncator         *spanTruncator
+
+	// secondaryCh hands spans off to secondary writer, so it never runs on the hot path of this one
+	secondaryCh      chan *model.Span
+	secondaryMu      sync.RWMutex
+	secondaryClosed  bool
+	secondaryDone    chan struct{}
+	secondaryErrors  metrics.Counter
+	secondaryDropped metrics.Counter
+
+	overflowSpooled     metrics.Counter
+	overflowSpoolErrors metrics.Counter
 }
 
 // NewSpanWriter creates writer interface implementation for YDB
 func NewSpanWriter(pool table.Client, metricsFactory metrics.Factory, logger *zap.Logger, jaegerLogger hclog.Logger, opts SpanWriterOptions) *SpanWriter {
-	cache, _ := lru.New(opts.OpCacheSize) // it's ok to ignore this error for negative size
 	batchOpts := batch.Options{
-		BufferSize:   opts.BufferSize,
-		BatchSize:    opts.BatchSize,
-		BatchWorkers: opts.BatchWorkers,
+		BufferSize:    opts.BufferSize,
+		BatchSize:     opts.BatchSize,
+		BatchWorkers:  opts.BatchWorkers,
+		BatchBytes:    opts.BatchBytes,
+		FlushInterval: opts.FlushInterval,
+		Overflow:      opts.Overflow,
+		BlockTimeout:  opts.OverflowTimeout,
 	}
 	writerOpts := BatchWriterOptions{
 		WriteTimeout:        opts.WriteTimeout,
 		RetryAttemptTimeout: opts.RetryAttemptTimeout,
 		DbPath:              opts.DbPath,
+		Spool:               opts.Spool,
+		Retry:               opts.Retry,
+		Partitions:          opts.Partitions,
 	}
-	var batchWriter batch.Writer
+	var batchWriter spanBatchWriter
 	if opts.ArchiveWriter {
 		batchWriter = NewArchiveWriter(pool, metricsFactory, logger, jaegerLogger, writerOpts)
 	} else {
 		batchWriter = NewBatchWriter(pool, metricsFactory, logger, jaegerLogger, writerOpts)
 	}
+	if opts.Spool != nil {
+		opts.Spool.Start(batchWriter.ReplayItems)
+	}
 	bq := batch.NewQueue(batchOpts, metricsFactory.Namespace(metrics.NSOptions{Name: "spans"}), batchWriter)
 	idx := indexer.NewIndexer(pool, metricsFactory, logger, jaegerLogger, indexer.Options{
 		DbPath:              opts.DbPath,
@@ -57,17 +111,52 @@ func NewSpanWriter(pool table.Client, metricsFactory metrics.Factory, logger *za
 		WriteTimeout:        opts.WriteTimeout,
 		RetryAttemptTimeout: opts.RetryAttemptTimeout,
 		Batch:               batchOpts,
+		Partitions:          opts.Partitions,
+		Archive:             opts.ArchiveWriter,
+		TagPolicy:           opts.TagPolicy,
 	})
-	return &SpanWriter{
+	w := &SpanWriter{
 		opts:              opts,
 		pool:              pool,
 		logger:            logger,
 		jaegerLogger:      jaegerLogger,
 		spanBatch:         bq,
+		batchWriter:       batchWriter,
 		indexer:           idx,
-		nameCache:         cache,
+		names:             newNameWriter(pool, metricsFactory.Namespace(metrics.NSOptions{Name: "names"}), logger, jaegerLogger, opts),
 		invalidateMetrics: newInvalidSpanMetrics(metricsFactory),
 	}
+	if !opts.ArchiveWriter && opts.DependencyWindow > 0 {
+		ns := metricsFactory.Namespace(metrics.NSOptions{Name: "dependencies"})
+		depWriter := dependencystore.NewWriter(pool, ns, dependencystore.WriterOptions{
+			DbPath:              opts.DbPath,
+			WriteTimeout:        opts.WriteTimeout,
+			RetryAttemptTimeout: opts.RetryAttemptTimeout,
+		})
+		w.dependencies = newDependencyAggregator(depWriter, ns, logger, jaegerLogger, opts)
+	}
+	if !opts.ArchiveWriter && opts.Sampler != nil {
+		w.sampler = opts.Sampler
+		w.sampler.Start(w.writeSampled)
+	}
+	if opts.Limits.enabled() {
+		w.truncator = newSpanTruncator(opts.Limits, metricsFactory)
+	}
+	if opts.Spool != nil {
+		w.overflowSpooled = metricsFactory.Counter(metrics.Options{Name: "overflow_spooled"})
+		w.overflowSpoolErrors = metricsFactory.Counter(metrics.Options{Name: "overflow_spool_errors"})
+	}
+	if opts.Secondary != nil {
+		w.secondaryErrors = metricsFactory.Counter(metrics.Options{Name: "secondary_errors"})
+		w.secondaryDropped = metricsFactory.Counter(metrics.Options{Name: "secondary_dropped"})
+		w.secondaryCh = make(chan *model.Span, opts.BufferSize)
+		w.secondaryDone = make(chan struct{})
+		go w.secondaryProcess()
+	}
+	if !opts.ArchiveWriter && opts.DedupWindow > 0 {
+		w.dedup = newSpanDedup(opts.DedupWindow, opts.DedupMaxSpans, metricsFactory.Namespace(metrics.NSOptions{Name: "dedup"}))
+	}
+	return w
 }
 
 // WriteSpan saves the span into YDB
@@ -80,76 +169,206 @@ func (s *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
 		s.invalidateMetrics.Inc(span.Process.ServiceName, span.OperationName)
 		return nil
 	}
-	err := s.spanBatch.Add(span)
+	// partitions for far future aren't created by watcher yet
+	if now := time.Now(); s.opts.MaxFutureSkew > 0 && span.StartTime.Sub(now) > s.opts.MaxFutureSkew {
+		s.invalidateMetrics.Inc(span.Process.ServiceName, span.OperationName)
+		if s.opts.FutureSkewPolicy != FutureSkewClamp {
+			return nil
+		}
+		clampStartTime(span, now)
+	}
+	// redact before anything is buffered, so original values reach neither spans table nor indexes
+	if s.opts.Redactor != nil {
+		s.opts.Redactor.Apply(span)
+	}
+	if s.truncator != nil {
+		s.truncator.Apply(span)
+	}
+	// limited after truncation, so bytes rate counts what is stored
+	if !s.opts.ArchiveWriter && s.opts.RateLimiter != nil && !s.opts.RateLimiter.Allow(span) {
+		return nil
+	}
+	if s.sampler != nil {
+		s.sampler.Add(span)
+		return nil
+	}
+	return s.writeSpan(ctx, span)
+}
+
+// writeSampled saves spans of traces kept by sampler, writes wait for buffer space no longer than OverflowTimeout
+func (s *SpanWriter) writeSampled(spans []*model.Span) {
+	timeout := s.opts.OverflowTimeout
+	if timeout <= 0 {
+		timeout = defaultSampledWriteTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, span := range spans {
+		if err := s.writeSpan(ctx, span); err != nil {
+			s.logger.Error("sampled span write error", zap.Error(err))
+			s.jaegerLogger.Error(
+				"Failed to save sampled span",
+				"error", err,
+			)
+		}
+	}
+}
+
+// writeSpan passes span accepted by this writer to secondary one, spans dropped by overflow of this writer
+// are accepted too. Rejected spans aren't passed, otherwise retry by collector would duplicate them.
+func (s *SpanWriter) writeSpan(ctx context.Context, span *model.Span) error {
+	err := s.storeSpan(ctx, span)
+	if err == nil && s.secondaryCh != nil {
+		s.addSecondary(span)
+	}
+	return err
+}
+
+func (s *SpanWriter) storeSpan(ctx context.Context, span *model.Span) error {
+	var (
+		dedupKey  spanDedupKey
+		duplicate bool
+	)
+	if s.dedup != nil {
+		dedupKey = newSpanDedupKey(span)
+		duplicate = s.dedup.Contains(dedupKey)
+		if duplicate && s.opts.DedupSpans {
+			return nil
+		}
+	}
+	err := s.spanBatch.AddContext(ctx, span)
 	if err != nil {
 		switch err {
 		case batch.ErrOverflow:
-			return nil
+			if !s.spoolSpan(span) {
+				return s.overflowError()
+			}
 		default:
 			return err
 		}
 	}
 
-	if !s.opts.ArchiveWriter {
-		_ = s.indexer.Add(span)
+	if !duplicate {
+		// span is queued already, so retry by collector just rewrites it
+		if err = s.indexer.AddContext(ctx, span); err != nil {
+			if err = s.overflowError(); err != nil {
+				return err
+			}
+		}
+	}
+	if s.dedup != nil && !duplicate {
+		s.dedup.Add(dedupKey)
+	}
+	if s.dependencies != nil && !duplicate {
+		s.dependencies.Add(span)
 	}
 
-	return s.saveServiceNameAndOperationName(ctx, span)
+	s.names.Add(span)
+	return nil
 }
 
-func (s *SpanWriter) saveServiceNameAndOperationName(ctx context.Context, span *model.Span) error {
-	serviceName := span.GetProcess().GetServiceName()
-	operationName := span.GetOperationName()
-	kind, _ := span.GetSpanKind()
-	if exists, _ := s.nameCache.ContainsOrAdd(serviceName, true); !exists {
-		data := types.ListValue(types.StructValue(
-			types.StructFieldValue("service_name", types.TextValue(serviceName)),
-		))
+// addSecondary queues span for secondary writer without waiting, span is dropped if queue is full
+func (s *SpanWriter) addSecondary(span *model.Span) {
+	s.secondaryMu.RLock()
+	defer s.secondaryMu.RUnlock()
+	if s.secondaryClosed {
+		s.secondaryDropped.Inc(1)
+		return
+	}
+	select {
+	case s.secondaryCh <- span:
+	default:
+		s.secondaryDropped.Inc(1)
+	}
+}
 
-		if s.opts.WriteTimeout > 0 {
-			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeout(ctx, s.opts.WriteTimeout)
-			defer cancel()
-		}
-		err := db.UpsertData(ctx, s.pool, s.opts.DbPath.FullTable("service_names"), data, s.opts.RetryAttemptTimeout)
-		if err != nil {
-			s.jaegerLogger.Error(
-				"Failed to save service name",
-				"service_name", serviceName,
-				"error", err,
-			)
+func (s *SpanWriter) secondaryProcess() {
+	defer close(s.secondaryDone)
+	for span := range s.secondaryCh {
+		s.writeSecondary(span)
+	}
+}
 
-			return err
-		}
+// writeSecondary copies span already transformed by this writer, its errors never affect this writer
+func (s *SpanWriter) writeSecondary(span *model.Span) {
+	if err := s.opts.Secondary.writeSpan(context.Background(), span); err != nil {
+		s.secondaryErrors.Inc(1)
+		s.logger.Error("secondary span write error", zap.Error(err))
+		s.jaegerLogger.Error(
+			"Failed to save span to secondary database",
+			"error", err,
+		)
 	}
-	if operationName == "" {
+}
+
+// overflowError returns nil for drop policy, so collector treats span as saved
+func (s *SpanWriter) overflowError() error {
+	if s.opts.Overflow == batch.OverflowDrop || s.opts.Overflow == "" {
 		return nil
 	}
-	if exists, _ := s.nameCache.ContainsOrAdd(serviceName+"-"+operationName+"-"+kind.String(), true); !exists {
-		data := types.ListValue(types.StructValue(
-			types.StructFieldValue("service_name", types.TextValue(serviceName)),
-			types.StructFieldValue("operation_name", types.TextValue(operationName)),
-			types.StructFieldValue("span_kind", types.TextValue(kind.String())),
-		))
-		if s.opts.WriteTimeout > 0 {
-			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeout(ctx, s.opts.WriteTimeout)
-			defer cancel()
-		}
-		err := db.UpsertData(ctx, s.pool, s.opts.DbPath.FullTable("operation_names_v2"), data, s.opts.RetryAttemptTimeout)
-		if err != nil {
-			s.jaegerLogger.Error(
-				"Failed to save operation name",
-				"operation_name", operationName,
-				"error", err,
-			)
-			return err
-		}
+	return ErrBufferFull
+}
+
+// spoolSpan saves span which doesn't fit into queue, returns false if it isn't spooled
+func (s *SpanWriter) spoolSpan(span *model.Span) bool {
+	if s.opts.Spool == nil {
+		return false
 	}
-	return nil
+	if !spoolSpans(s.opts.Spool, s.logger, []*model.Span{span}) {
+		s.overflowSpoolErrors.Inc(1)
+		return false
+	}
+	s.overflowSpooled.Inc(1)
+	return true
+}
+
+// Close flushes buffered spans and index entries until ctx is done
+func (s *SpanWriter) Close(ctx context.Context) CloseStats {
+	if s.sampler != nil {
+		s.sampler.Close()
+	}
+	if s.secondaryCh != nil {
+		s.closeSecondary(ctx)
+	}
+	spans := s.spanBatch.Close(ctx)
+	s.batchWriter.Close()
+	s.logger.Info("span queue closed", zap.Bool("archive", s.opts.ArchiveWriter),
+		zap.Int64("flushed", spans.Flushed), zap.Int64("lost", spans.Lost))
+	names := s.names.Close(ctx)
+	s.logger.Info("name queue closed", zap.Bool("archive", s.opts.ArchiveWriter),
+		zap.Int64("flushed", names.Flushed), zap.Int64("lost", names.Lost))
+	idx := s.indexer.Close(ctx)
+	s.logger.Info("indexer closed", zap.Bool("archive", s.opts.ArchiveWriter),
+		zap.Int64("flushed", idx.Entries.Flushed), zap.Int64("lost", idx.Entries.Lost), zap.Int64("lost_spans", idx.LostSpans))
+	if s.dependencies != nil {
+		s.dependencies.Close()
+	}
+	if s.opts.Spool != nil {
+		_ = s.opts.Spool.Close()
+	}
+	return CloseStats{Spans: spans, Names: names, Index: idx, DroppedSpans: s.batchWriter.Dropped()}
 }
 
-func (s *SpanWriter) Close() {
-	s.spanBatch.Close()
-	s.indexer.Close()
+// closeSecondary passes spans left in queue to secondary writer until ctx is done, the rest are dropped.
+// Secondary writer itself is closed by its owner.
+func (s *SpanWriter) closeSecondary(ctx context.Context) {
+	s.secondaryMu.Lock()
+	if s.secondaryClosed {
+		s.secondaryMu.Unlock()
+		return
+	}
+	s.secondaryClosed = true
+	close(s.secondaryCh)
+	s.secondaryMu.Unlock()
+	select {
+	case <-s.secondaryDone:
+	case <-ctx.Done():
+		var dropped int64
+		for range s.secondaryCh {
+			dropped++
+		}
+		s.secondaryDropped.Inc(dropped)
+		s.logger.Warn("secondary queue isn't drained", zap.Int64("dropped", dropped))
+		<-s.secondaryDone
+	}
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
 
 	"github.com/ydb-platform/jaeger-ydb-store/internal/testutil"
 	"github.com/ydb-platform/jaeger-ydb-store/schema"
@@ -82,3 +83,24 @@ func setUpReader(t *testing.T) *reader.SpanReader {
 		testutil.JaegerLogger(),
 	)
 }
+
+func TestSpanWriter_AddSecondary(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	w := &SpanWriter{
+		logger:           testutil.Zap(),
+		secondaryCh:      make(chan *model.Span, 1),
+		secondaryDone:    make(chan struct{}),
+		secondaryDropped: mf.Counter(metrics.Options{Name: "secondary_dropped"}),
+	}
+	span := &model.Span{TraceID: model.NewTraceID(1, 2), SpanID: 3}
+	w.addSecondary(span)
+	// queue is full, span is dropped instead of waiting for secondary writer
+	w.addSecondary(span)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "secondary_dropped", Value: 1})
+	assert.Equal(t, span, <-w.secondaryCh)
+
+	close(w.secondaryDone)
+	w.closeSecondary(context.Background())
+	w.addSecondary(span)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "secondary_dropped", Value: 2})
+}